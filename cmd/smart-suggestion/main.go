@@ -66,6 +66,16 @@ type DeepSeekRequest = OpenAIRequest
 type DeepSeekResponse = OpenAIResponse
 type DeepSeekError = OpenAIError
 
+// Mistral and OpenRouter APIs are OpenAI-compatible, reuse the same structures
+type MistralResponse = OpenAIResponse
+type OpenRouterResponse = OpenAIResponse
+
+// Ollama API structures
+type OllamaResponse struct {
+	Message OpenAIMessage `json:"message"`
+	Error   string        `json:"error,omitempty"`
+}
+
 // parseAndExtractCommand parses the raw response from the AI model,
 // separating the reasoning from the command.
 func parseAndExtractCommand(response string) string {
@@ -351,10 +361,22 @@ func main() {
 		Run:   runConfigValidate,
 	}
 
+	// Add privacy command with subcommands
+	var privacyCmd = &cobra.Command{
+		Use:   "privacy",
+		Short: "Inspect privacy filtering",
+	}
+
+	var privacyListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the privacy patterns active at the configured level",
+		Run:   runPrivacyList,
+	}
+
 	// Root command flags
-	rootCmd.Flags().StringVarP(&provider, "provider", "p", "", "AI provider (openai, openai_compatible, azure_openai, anthropic, gemini, or deepseek). If not specified, uses default_provider from config file")
+	rootCmd.Flags().StringVarP(&provider, "provider", "p", "", "AI provider (openai, openai_compatible, azure_openai, anthropic, gemini, deepseek, mistral, openrouter, or ollama). If not specified, uses default_provider from config file")
 	rootCmd.Flags().StringVarP(&input, "input", "i", "", "User input")
-	rootCmd.Flags().StringVarP(&systemPrompt, "system", "s", "", "System prompt (optional, uses default if not provided)")
+	rootCmd.Flags().StringVarP(&systemPrompt, "system", "s", "", "System prompt (optional, uses the provider's system_prompt or the default if not provided)")
 	rootCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug logging")
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "/tmp/smart_suggestion", "Output file path")
 	rootCmd.Flags().BoolVarP(&sendContext, "context", "c", false, "Include context information")
@@ -373,7 +395,9 @@ func main() {
 
 	// Config command flags
 	configInitCmd.Flags().StringP("file", "f", "", "Write configuration to file instead of stdout")
+	configInitCmd.Flags().Bool("minimal", false, "Only include the default provider's settings")
 	configValidateCmd.Flags().StringP("file", "f", "", "Configuration file path (default: $SMART_SUGGESTION_PROVIDER_FILE)")
+	configValidateCmd.Flags().Bool("check-connectivity", false, "Also check that each configured provider's base URL is reachable")
 
 	// Add config subcommands
 	configCmd.AddCommand(configInitCmd)
@@ -385,6 +409,9 @@ func main() {
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(configCmd)
 
+	privacyCmd.AddCommand(privacyListCmd)
+	rootCmd.AddCommand(privacyCmd)
+
 	// Only require input for the main fetch command (provider is optional, will use default from config)
 	if len(os.Args) > 1 && os.Args[1] != "proxy" && os.Args[1] != "rotate-logs" {
 		rootCmd.MarkFlagRequired("input")
@@ -402,11 +429,16 @@ func main() {
 }
 
 func runFetch(cmd *cobra.Command, args []string) {
-	// Load configuration to get default provider if needed
+	// Load configuration to get the default provider and system prompt if needed
+	var cfg *config.Config
+	var cfgErr error
+	if provider == "" || systemPrompt == "" {
+		cfg, cfgErr = config.LoadConfigFromEnv()
+	}
+
 	if provider == "" {
-		cfg, err := config.LoadConfigFromEnv()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to load configuration: %v\n", err)
+		if cfgErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to load configuration: %v\n", cfgErr)
 			fmt.Fprintf(os.Stderr, "Please set SMART_SUGGESTION_PROVIDER_FILE environment variable or use --provider flag\n")
 			os.Exit(1)
 		}
@@ -425,6 +457,9 @@ func runFetch(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if systemPrompt == "" && cfgErr == nil {
+		systemPrompt = cfg.SystemPrompt(strings.ToLower(provider))
+	}
 	if systemPrompt == "" {
 		systemPrompt = defaultSystemPrompt
 	}
@@ -464,6 +499,12 @@ func runFetch(cmd *cobra.Command, args []string) {
 		suggestion, err = fetchGemini()
 	case "deepseek":
 		suggestion, err = fetchDeepSeek()
+	case "mistral":
+		suggestion, err = fetchMistral()
+	case "openrouter":
+		suggestion, err = fetchOpenRouter()
+	case "ollama":
+		suggestion, err = fetchOllama()
 	default:
 		err = fmt.Errorf("unsupported provider: %s", provider)
 	}
@@ -568,6 +609,8 @@ func fetchOpenAI() (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
+	applyConfiguredHeaders(req, cfg, "openai")
+
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -687,6 +730,8 @@ func fetchOpenAICompatible() (string, error) {
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
 
+	applyConfiguredHeaders(req, cfg, "openai_compatible")
+
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -834,6 +879,8 @@ func fetchAzureOpenAI() (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("api-key", apiKey) // Azure OpenAI uses "api-key" header
 
+	applyConfiguredHeaders(req, cfg, "azure_openai")
+
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -939,7 +986,13 @@ func fetchAnthropic() (string, error) {
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	anthropicVersion := "2023-06-01"
+	if cfg.Anthropic != nil && cfg.Anthropic.APIVersion != "" {
+		anthropicVersion = cfg.Anthropic.APIVersion
+	}
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	applyConfiguredHeaders(req, cfg, "anthropic")
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
@@ -984,6 +1037,14 @@ func fetchAnthropic() (string, error) {
 	return response.Content[0].Text, nil
 }
 
+// applyConfiguredHeaders sets the global and provider-specific headers from the config
+// on req. They are applied last, so they override the defaults set by the caller.
+func applyConfiguredHeaders(req *http.Request, cfg *config.Config, provider string) {
+	for name, value := range cfg.EffectiveHeaders(provider) {
+		req.Header.Set(name, value)
+	}
+}
+
 // writeToLogFile writes content to a log file with automatic rotation
 func writeToLogFile(logFilePath, content string) error {
 	// Check and rotate log file if necessary
@@ -1263,6 +1324,8 @@ func fetchGemini() (string, error) {
 
 	req.Header.Set("Content-Type", "application/json")
 
+	applyConfiguredHeaders(req, cfg, "gemini")
+
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -2115,6 +2178,8 @@ func fetchDeepSeek() (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
+	applyConfiguredHeaders(req, cfg, "deepseek")
+
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -2154,6 +2219,321 @@ func fetchDeepSeek() (string, error) {
 	return response.Choices[0].Message.Content, nil
 }
 
+func fetchMistral() (string, error) {
+	cfg, err := config.LoadConfigFromEnv()
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	apiKey, err := cfg.GetAPIKey("mistral")
+	if err != nil {
+		return "", fmt.Errorf("Mistral API key not configured: %w", err)
+	}
+
+	baseURL := "https://api.mistral.ai"
+	if cfg.Mistral != nil && cfg.Mistral.BaseURL != "" {
+		baseURL = cfg.Mistral.BaseURL
+	}
+
+	// Handle different base URL formats
+	var url string
+	if strings.HasPrefix(baseURL, "http://") || strings.HasPrefix(baseURL, "https://") {
+		// Base URL already includes protocol
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		url = fmt.Sprintf("%s/v1/chat/completions", baseURL)
+	} else {
+		// Base URL is just hostname, add https protocol
+		url = fmt.Sprintf("https://%s/v1/chat/completions", baseURL)
+	}
+
+	// Get model from configuration or use default
+	model := "mistral-small-latest"
+	if cfg.Mistral != nil && cfg.Mistral.Model != "" {
+		model = cfg.Mistral.Model
+	}
+
+	// Build request map to support extra_body
+	requestMap := map[string]interface{}{
+		"model": model,
+		"messages": []OpenAIMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: input},
+		},
+	}
+
+	// Merge extra_body if configured
+	if cfg.Mistral != nil {
+		requestMap = cfg.Mistral.MergeExtraBody(requestMap)
+	}
+
+	jsonData, err := json.Marshal(requestMap)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if debug {
+		logDebug("Sending Mistral request", map[string]any{
+			"url":     url,
+			"request": string(jsonData),
+		})
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	applyConfiguredHeaders(req, cfg, "mistral")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if debug {
+		logDebug("Received Mistral response", map[string]any{
+			"status":   resp.Status,
+			"response": string(body),
+		})
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response MistralResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Error != nil {
+		return "", fmt.Errorf("Mistral API error: %s", response.Error.Message)
+	}
+
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("no choices returned from Mistral API")
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
+
+func fetchOpenRouter() (string, error) {
+	cfg, err := config.LoadConfigFromEnv()
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	apiKey, err := cfg.GetAPIKey("openrouter")
+	if err != nil {
+		return "", fmt.Errorf("OpenRouter API key not configured: %w", err)
+	}
+
+	baseURL := "https://openrouter.ai/api"
+	if cfg.OpenRouter != nil && cfg.OpenRouter.BaseURL != "" {
+		baseURL = cfg.OpenRouter.BaseURL
+	}
+
+	// Handle different base URL formats
+	var url string
+	if strings.HasPrefix(baseURL, "http://") || strings.HasPrefix(baseURL, "https://") {
+		// Base URL already includes protocol
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		url = fmt.Sprintf("%s/v1/chat/completions", baseURL)
+	} else {
+		// Base URL is just hostname, add https protocol
+		url = fmt.Sprintf("https://%s/v1/chat/completions", baseURL)
+	}
+
+	// Get model from configuration or use default
+	model := "openai/gpt-4o-mini" // OpenRouter model IDs are prefixed with the upstream vendor
+	if cfg.OpenRouter != nil && cfg.OpenRouter.Model != "" {
+		model = cfg.OpenRouter.Model
+	}
+
+	// Build request map to support extra_body
+	requestMap := map[string]interface{}{
+		"model": model,
+		"messages": []OpenAIMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: input},
+		},
+	}
+
+	// Merge extra_body if configured
+	if cfg.OpenRouter != nil {
+		requestMap = cfg.OpenRouter.MergeExtraBody(requestMap)
+	}
+
+	jsonData, err := json.Marshal(requestMap)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if debug {
+		logDebug("Sending OpenRouter request", map[string]any{
+			"url":     url,
+			"request": string(jsonData),
+		})
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	applyConfiguredHeaders(req, cfg, "openrouter")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if debug {
+		logDebug("Received OpenRouter response", map[string]any{
+			"status":   resp.Status,
+			"response": string(body),
+		})
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response OpenRouterResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Error != nil {
+		return "", fmt.Errorf("OpenRouter API error: %s", response.Error.Message)
+	}
+
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("no choices returned from OpenRouter API")
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
+
+func fetchOllama() (string, error) {
+	cfg, err := config.LoadConfigFromEnv()
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Ollama runs locally and does not require an API key
+	apiKey, _ := cfg.GetAPIKey("ollama")
+
+	baseURL := "http://localhost:11434"
+	if cfg.Ollama != nil && cfg.Ollama.BaseURL != "" {
+		baseURL = cfg.Ollama.BaseURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	url := fmt.Sprintf("%s/api/chat", baseURL)
+
+	model := "llama3.2:latest"
+	if cfg.Ollama != nil && cfg.Ollama.Model != "" {
+		model = cfg.Ollama.Model
+	}
+
+	// Build request map to support extra_body
+	requestMap := map[string]interface{}{
+		"model": model,
+		"messages": []OpenAIMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: input},
+		},
+		"stream": false,
+	}
+
+	// Merge extra_body if configured
+	if cfg.Ollama != nil {
+		requestMap = cfg.Ollama.MergeExtraBody(requestMap)
+	}
+
+	jsonData, err := json.Marshal(requestMap)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if debug {
+		logDebug("Sending Ollama request", map[string]any{
+			"url":     url,
+			"request": string(jsonData),
+		})
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	applyConfiguredHeaders(req, cfg, "ollama")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if debug {
+		logDebug("Received Ollama response", map[string]any{
+			"status":   resp.Status,
+			"response": string(body),
+		})
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response OllamaResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Error != "" {
+		return "", fmt.Errorf("Ollama API error: %s", response.Error)
+	}
+
+	if response.Message.Content == "" {
+		return "", fmt.Errorf("no message returned from Ollama API")
+	}
+
+	return response.Message.Content, nil
+}
+
 func runUpdate(cmd *cobra.Command, args []string) {
 	checkOnly, _ := cmd.Flags().GetBool("check-only")
 
@@ -2425,9 +2805,13 @@ func copyFile(src, dst string) error {
 // runConfigInit initializes a new configuration file
 func runConfigInit(cmd *cobra.Command, args []string) {
 	configFile, _ := cmd.Flags().GetString("file")
+	minimal, _ := cmd.Flags().GetBool("minimal")
 	
 	// Create default configuration
 	defaultConfig := config.DefaultConfig()
+	if minimal {
+		defaultConfig = config.MinimalDefaultConfig()
+	}
 	
 	if configFile == "" {
 		// No file specified, output to stdout
@@ -2497,9 +2881,9 @@ func runConfigValidate(cmd *cobra.Command, args []string) {
 	
 	// Check which providers are configured
 	fmt.Println("\nConfigured providers:")
-	providers := []string{"openai", "openai_compatible", "azure_openai", "anthropic", "gemini", "deepseek"}
+	providers := []string{"openai", "openai_compatible", "azure_openai", "anthropic", "gemini", "deepseek", "mistral", "openrouter", "ollama"}
 	for _, provider := range providers {
-		if _, err := cfg.GetAPIKey(provider); err == nil {
+		if err := cfg.ValidateProviderAvailable(provider); err == nil {
 			fmt.Printf("  ✓ %s\n", provider)
 		} else {
 			fmt.Printf("  ✗ %s (not configured)\n", provider)
@@ -2509,6 +2893,36 @@ func runConfigValidate(cmd *cobra.Command, args []string) {
 	if cfg.DefaultProvider != "" {
 		fmt.Printf("\nDefault provider: %s\n", cfg.DefaultProvider)
 	}
+
+	if checkConnectivity, _ := cmd.Flags().GetBool("check-connectivity"); checkConnectivity {
+		fmt.Println("\nConnectivity:")
+		for _, provider := range providers {
+			if cfg.ValidateProviderAvailable(provider) != nil {
+				continue
+			}
+			if err := cfg.CheckConnectivity(provider, 10*time.Second); err != nil {
+				fmt.Printf("  ✗ %s (%v)\n", provider, err)
+			} else {
+				fmt.Printf("  ✓ %s\n", provider)
+			}
+		}
+	}
+}
+
+// runPrivacyList prints the privacy patterns active at the configured level
+func runPrivacyList(cmd *cobra.Command, args []string) {
+	filterConfig := getPrivacyFilterConfigFromEnv()
+	if !filterConfig.Enabled {
+		fmt.Println("Privacy filtering is disabled")
+		return
+	}
+
+	fmt.Printf("Level: %s\n\n", privacy.DescribeLevel(filterConfig.Level))
+
+	filter := privacy.NewFilter(filterConfig)
+	for _, pattern := range filter.ActivePatterns() {
+		fmt.Printf("  %-32s %-12s severity %d\n", pattern.Name, pattern.Category, pattern.Severity)
+	}
 }
 
 // getPrivacyFilterConfigFromEnv returns privacy filter configuration based on environment variables and config file
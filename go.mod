@@ -5,8 +5,10 @@ go 1.23.0
 toolchain go1.23.1
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/creack/pty v1.1.24
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/crypto v0.38.0
 	golang.org/x/term v0.32.0
 )
 
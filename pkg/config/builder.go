@@ -0,0 +1,40 @@
+package config
+
+// ConfigBuilder provides a fluent way to construct a Config programmatically
+type ConfigBuilder struct {
+	config *Config
+	err    error
+}
+
+// NewConfigBuilder creates a builder for an empty configuration
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{config: &Config{}}
+}
+
+// WithProvider sets the configuration for the specified provider
+func (b *ConfigBuilder) WithProvider(provider string, pc *ProviderConfig) *ConfigBuilder {
+	if b.err != nil {
+		return b
+	}
+	b.err = b.config.SetProviderConfig(provider, pc)
+	return b
+}
+
+// WithDefaultProvider sets the default provider
+func (b *ConfigBuilder) WithDefaultProvider(provider string) *ConfigBuilder {
+	b.config.DefaultProvider = provider
+	return b
+}
+
+// Build normalizes base URLs, validates and returns the configuration.
+// The first error encountered while building is returned before validation runs.
+func (b *ConfigBuilder) Build() (*Config, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.config.NormalizeBaseURLs()
+	if err := b.config.Validate(); err != nil {
+		return nil, err
+	}
+	return b.config, nil
+}
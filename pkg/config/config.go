@@ -3,19 +3,58 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/yetone/smart-suggestion/pkg/privacy"
 )
 
 // ProviderConfig represents the configuration for a single AI provider
 type ProviderConfig struct {
-	APIKey     string                 `json:"api_key,omitempty"`
-	BaseURL    string                 `json:"base_url,omitempty"`
-	Model      string                 `json:"model,omitempty"`
-	APIVersion string                 `json:"api_version,omitempty"`
-	ExtraBody  map[string]interface{} `json:"extra_body,omitempty"`
+	APIKey       string `json:"api_key,omitempty"`
+	BaseURL      string `json:"base_url,omitempty"`
+	Model        string `json:"model,omitempty"`
+	APIVersion   string `json:"api_version,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Project      string `json:"project,omitempty"`
+	// APIKeyEnv names the environment variable ResolveAPIKey reads when api_key is empty,
+	// instead of the provider's conventional one such as OPENAI_API_KEY
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// EmbeddingBaseURL routes embedding requests to a different base URL than chat
+	// completions. It falls back to BaseURL when empty.
+	EmbeddingBaseURL string                 `json:"embedding_base_url,omitempty"`
+	ExtraBody        map[string]interface{} `json:"extra_body,omitempty"`
+	// Headers are extra HTTP headers sent with every request to this provider.
+	// They override GlobalHeaders with the same name, see Config.EffectiveHeaders.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Enabled set to false keeps the provider's settings in the file without offering
+	// or validating it. Nil means enabled.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Retry controls how requests that fail with a transient error, such as a 429 or
+	// 5xx response, are retried. Nil means requests are not retried.
+	Retry *RetryConfig `json:"retry,omitempty"`
+	// Stream set to false asks for complete responses instead of SSE streaming, for
+	// backends that do not support it. Nil means streaming is used.
+	Stream *bool `json:"stream,omitempty"`
+	// SystemPrompt replaces the built-in system prompt for requests to this provider.
+	// A prompt given on the command line takes precedence.
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+// RetryConfig describes exponential backoff for retrying provider requests. The wait
+// before each retry starts at InitialBackoffMs and is multiplied by Multiplier after
+// every attempt, up to MaxBackoffMs.
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first request
+	MaxAttempts      int     `json:"max_attempts,omitempty"`
+	InitialBackoffMs int     `json:"initial_backoff_ms,omitempty"`
+	MaxBackoffMs     int     `json:"max_backoff_ms,omitempty"`
+	Multiplier       float64 `json:"multiplier,omitempty"`
 }
 
 // AzureOpenAIConfig represents specific configuration for Azure OpenAI
@@ -27,6 +66,9 @@ type AzureOpenAIConfig struct {
 
 // Config represents the complete application configuration
 type Config struct {
+	// Version is the config schema version, see Migrate
+	Version int `json:"version,omitempty"`
+
 	// Provider configurations
 	OpenAI           *ProviderConfig    `json:"openai,omitempty"`
 	OpenAICompatible *ProviderConfig    `json:"openai_compatible,omitempty"`
@@ -34,15 +76,43 @@ type Config struct {
 	Anthropic        *ProviderConfig    `json:"anthropic,omitempty"`
 	Gemini           *ProviderConfig    `json:"gemini,omitempty"`
 	DeepSeek         *ProviderConfig    `json:"deepseek,omitempty"`
+	Mistral          *ProviderConfig    `json:"mistral,omitempty"`
+	OpenRouter       *ProviderConfig    `json:"openrouter,omitempty"`
+	Ollama           *ProviderConfig    `json:"ollama,omitempty"`
+
+	// CustomProviders configures the providers added with RegisterProvider, keyed by
+	// their registered name
+	CustomProviders map[string]*ProviderConfig `json:"custom_providers,omitempty"`
 
 	// General settings
 	DefaultProvider string                    `json:"default_provider,omitempty"`
 	PrivacyFilter   *privacy.FilterConfig    `json:"privacy_filter,omitempty"`
+
+	// BaseURLRequireScheme disables prepending https:// to base URLs without a scheme
+	BaseURLRequireScheme bool `json:"base_url_require_scheme,omitempty"`
+
+	// RequireHTTPS rejects http:// base URLs during validation, except for loopback
+	// hosts such as a local Ollama server
+	RequireHTTPS bool `json:"require_https,omitempty"`
+
+	// GlobalHeaders are extra HTTP headers sent with requests to every provider,
+	// such as a Proxy-Authorization header for a corporate proxy
+	GlobalHeaders map[string]string `json:"global_headers,omitempty"`
+
+	// sourcePaths are the files the config was loaded from, see SourcePaths
+	sourcePaths []string
+	// overrides are the settings Reload re-applies, see SetOverride
+	overrides map[string]interface{}
+	// defaulted names the provider blocks mergeConfigs filled in from the defaults
+	defaulted map[string]bool
 }
 
-// DefaultConfig returns a configuration with default values
+// DefaultConfig returns a configuration with default values for every provider.
+// Use it as the base that loaded configurations are merged onto, or when a
+// template listing all providers is wanted.
 func DefaultConfig() *Config {
 	return &Config{
+		Version:         CurrentConfigVersion,
 		DefaultProvider: "openai",
 		PrivacyFilter:   privacy.DefaultFilterConfig(),
 		OpenAI: &ProviderConfig{
@@ -59,8 +129,9 @@ func DefaultConfig() *Config {
 			},
 		},
 		Anthropic: &ProviderConfig{
-			BaseURL: "https://api.anthropic.com",
-			Model:   "claude-3-5-sonnet-20241022",
+			BaseURL:    "https://api.anthropic.com",
+			Model:      "claude-3-5-sonnet-20241022",
+			APIVersion: "2023-06-01",
 		},
 		Gemini: &ProviderConfig{
 			BaseURL: "https://generativelanguage.googleapis.com",
@@ -70,6 +141,165 @@ func DefaultConfig() *Config {
 			BaseURL: "https://api.deepseek.com",
 			Model:   "deepseek-chat",
 		},
+		Mistral: &ProviderConfig{
+			BaseURL: "https://api.mistral.ai",
+			Model:   "mistral-small-latest",
+		},
+		OpenRouter: &ProviderConfig{
+			BaseURL: "https://openrouter.ai/api",
+			Model:   "openai/gpt-4o-mini",
+		},
+		Ollama: &ProviderConfig{
+			BaseURL: "http://localhost:11434",
+			Model:   "llama3.2:latest",
+		},
+		CustomProviders: registeredProviderDefaults(),
+	}
+}
+
+// DefaultConfigFor returns a configuration with only the given provider's defaults
+// populated and set as the default provider
+func DefaultConfigFor(provider string) (*Config, error) {
+	if !isValidProvider(provider) {
+		return nil, fmt.Errorf("unknown provider: %s", provider)
+	}
+
+	defaults := DefaultConfig()
+	config := &Config{
+		Version:         defaults.Version,
+		DefaultProvider: provider,
+		PrivacyFilter:   defaults.PrivacyFilter,
+	}
+
+	switch provider {
+	case "openai":
+		config.OpenAI = defaults.OpenAI
+	case "openai_compatible":
+		config.OpenAICompatible = defaults.OpenAICompatible
+	case "azure_openai":
+		config.AzureOpenAI = defaults.AzureOpenAI
+	case "anthropic":
+		config.Anthropic = defaults.Anthropic
+	case "gemini":
+		config.Gemini = defaults.Gemini
+	case "deepseek":
+		config.DeepSeek = defaults.DeepSeek
+	case "mistral":
+		config.Mistral = defaults.Mistral
+	case "openrouter":
+		config.OpenRouter = defaults.OpenRouter
+	case "ollama":
+		config.Ollama = defaults.Ollama
+	default:
+		config.CustomProviders = map[string]*ProviderConfig{provider: defaults.CustomProviders[provider]}
+	}
+
+	return config, nil
+}
+
+// MinimalDefaultConfig returns a configuration that only sets DefaultProvider and
+// that provider's block. Use it when writing a new config file so users start from
+// a short file instead of one listing every provider.
+func MinimalDefaultConfig() *Config {
+	config, _ := DefaultConfigFor(DefaultConfig().DefaultProvider)
+	return config
+}
+
+// Clone returns a deep copy of the configuration
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+
+	clone := *c
+	clone.OpenAI = cloneProviderConfig(c.OpenAI)
+	clone.OpenAICompatible = cloneProviderConfig(c.OpenAICompatible)
+	clone.Anthropic = cloneProviderConfig(c.Anthropic)
+	clone.Gemini = cloneProviderConfig(c.Gemini)
+	clone.DeepSeek = cloneProviderConfig(c.DeepSeek)
+	clone.Mistral = cloneProviderConfig(c.Mistral)
+	clone.OpenRouter = cloneProviderConfig(c.OpenRouter)
+	clone.Ollama = cloneProviderConfig(c.Ollama)
+
+	if c.AzureOpenAI != nil {
+		azure := *c.AzureOpenAI
+		azure.ProviderConfig = *cloneProviderConfig(&c.AzureOpenAI.ProviderConfig)
+		clone.AzureOpenAI = &azure
+	}
+
+	if c.CustomProviders != nil {
+		clone.CustomProviders = make(map[string]*ProviderConfig, len(c.CustomProviders))
+		for name, pc := range c.CustomProviders {
+			clone.CustomProviders[name] = cloneProviderConfig(pc)
+		}
+	}
+
+	clone.PrivacyFilter = c.PrivacyFilter.Clone()
+	clone.GlobalHeaders = cloneHeaders(c.GlobalHeaders)
+	clone.sourcePaths = slices.Clone(c.sourcePaths)
+	clone.defaulted = maps.Clone(c.defaulted)
+	if c.overrides != nil {
+		clone.overrides = deepCopyValue(c.overrides).(map[string]interface{})
+	}
+
+	return &clone
+}
+
+// cloneProviderConfig returns a deep copy of a provider configuration
+func cloneProviderConfig(pc *ProviderConfig) *ProviderConfig {
+	if pc == nil {
+		return nil
+	}
+
+	clone := *pc
+	if pc.ExtraBody != nil {
+		clone.ExtraBody = deepCopyValue(pc.ExtraBody).(map[string]interface{})
+	}
+	clone.Headers = cloneHeaders(pc.Headers)
+	if pc.Enabled != nil {
+		enabled := *pc.Enabled
+		clone.Enabled = &enabled
+	}
+	if pc.Retry != nil {
+		retry := *pc.Retry
+		clone.Retry = &retry
+	}
+	if pc.Stream != nil {
+		stream := *pc.Stream
+		clone.Stream = &stream
+	}
+	return &clone
+}
+
+// cloneHeaders returns a copy of a header map, or nil if headers is nil
+func cloneHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(headers))
+	for name, value := range headers {
+		clone[name] = value
+	}
+	return clone
+}
+
+// deepCopyValue recursively copies maps and slices decoded from JSON
+func deepCopyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopyValue(item)
+		}
+		return copied
+	default:
+		return v
 	}
 }
 
@@ -88,9 +318,141 @@ func GetDefaultConfigPath() (string, error) {
 	return filepath.Join(configDir, "config.json"), nil
 }
 
+// projectConfigName is the per-directory config file searched last
+const projectConfigName = ".smart-suggestion.json"
+
+// GetConfigSearchPaths returns the candidate config file locations in the order they
+// are searched: $SMART_SUGGESTION_PROVIDER_FILE, $XDG_CONFIG_HOME/smart-suggestion/config.json,
+// ~/.config/smart-suggestion/config.json and ./.smart-suggestion.json. Unset variables
+// are skipped and duplicate locations are listed once. Unlike GetDefaultConfigPath it
+// does not create any directories.
+func GetConfigSearchPaths() []string {
+	var candidates []string
+
+	if envPath := os.Getenv("SMART_SUGGESTION_PROVIDER_FILE"); envPath != "" {
+		candidates = append(candidates, envPath)
+	}
+	if xdgHome := os.Getenv("XDG_CONFIG_HOME"); xdgHome != "" {
+		candidates = append(candidates, filepath.Join(xdgHome, "smart-suggestion", "config.json"))
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(homeDir, ".config", "smart-suggestion", "config.json"))
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		candidates = append(candidates, filepath.Join(cwd, projectConfigName))
+	} else {
+		candidates = append(candidates, projectConfigName)
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	paths := make([]string, 0, len(candidates))
+	for _, path := range candidates {
+		cleaned := filepath.Clean(path)
+		if seen[cleaned] {
+			continue
+		}
+		seen[cleaned] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// FindConfig returns the first path from GetConfigSearchPaths that exists and is a
+// regular file. If none exists, the error lists every location that was searched.
+func FindConfig() (string, error) {
+	paths := GetConfigSearchPaths()
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no config file found in: %s", strings.Join(paths, ", "))
+}
+
+// CheckConfigFile reports whether a config file exists at path and can be read, so
+// callers can tell a missing file apart from one that cannot be used. err is nil when
+// the file is readable and otherwise describes the problem: the file is missing, path
+// is a directory, or the file cannot be opened, for example because permission is denied.
+func CheckConfigFile(path string) (exists bool, readable bool, err error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, false, fmt.Errorf("config file not found: %s", path)
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to access config file: %w", err)
+	}
+	if info.IsDir() {
+		return true, false, fmt.Errorf("config path is a directory, expected a file: %s", path)
+	}
+
+	file, err := os.Open(path)
+	if os.IsPermission(err) {
+		return true, false, fmt.Errorf("config file is not readable, check its permissions: %s", path)
+	}
+	if err != nil {
+		return true, false, fmt.Errorf("failed to open config file: %w", err)
+	}
+	file.Close()
+	return true, true, nil
+}
+
 // LoadConfig loads configuration from the specified file path
-// If the file doesn't exist, returns an error
+// If the file doesn't exist, returns an error. Files ending in .toml are parsed as TOML.
 func LoadConfig(configPath string) (*Config, error) {
+	return LoadConfigAndMigrate(configPath, false)
+}
+
+// LoadConfigAndMigrate loads configuration from the specified file path, migrating it
+// to the current schema version. If rewrite is set and the migration changed the
+// config, the migrated config is written back to the file before defaults are merged.
+func LoadConfigAndMigrate(configPath string, rewrite bool) (*Config, error) {
+	return loadConfig(configPath, rewrite, false)
+}
+
+// LoadConfigStrictSchema loads configuration like LoadConfig, but rejects a file that
+// sets a field the config schema does not define, such as a misspelled "modle", with
+// an error naming the field. LoadConfig ignores unknown fields.
+func LoadConfigStrictSchema(configPath string) (*Config, error) {
+	return loadConfig(configPath, false, true)
+}
+
+// loadConfig implements LoadConfigAndMigrate, rejecting unknown fields when strict is set
+func loadConfig(configPath string, rewrite, strict bool) (*Config, error) {
+	if configPath == "" {
+		return nil, fmt.Errorf("config file path is required")
+	}
+
+	// Check that the config file exists and can be read
+	if _, _, err := CheckConfigFile(configPath); err != nil {
+		return nil, err
+	}
+
+	config, err := decodeConfigFile(configPath, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	version := config.Version
+	if err := config.Migrate(); err != nil {
+		return nil, err
+	}
+	if rewrite && config.Version != version {
+		if err := config.SaveConfig(configPath); err != nil {
+			return nil, fmt.Errorf("failed to rewrite migrated config: %w", err)
+		}
+	}
+
+	// Merge with defaults for missing values
+	defaultConfig := DefaultConfig()
+	mergeConfigs(config, defaultConfig)
+	config.NormalizeBaseURLs()
+
+	return config, nil
+}
+
+// LoadConfigRaw loads configuration from the specified file path exactly as written,
+// without migrating, normalizing or merging defaults. Unspecified providers are nil.
+func LoadConfigRaw(configPath string) (*Config, error) {
 	if configPath == "" {
 		return nil, fmt.Errorf("config file path is required")
 	}
@@ -100,23 +462,199 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("config file not found: %s", configPath)
 	}
 
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	return readConfigFile(configPath)
+}
+
+// LoadConfigLayered loads each existing config file in order and merges later files
+// over earlier ones setting by setting, so a setting a later file contains wins and
+// every other setting is inherited. Objects such as privacy_filter, provider blocks
+// and header maps are merged key by key, with header names compared case-insensitively;
+// lists such as custom_patterns are replaced whole. Missing files are skipped.
+// Defaults are merged in last for any values still missing.
+func LoadConfigLayered(paths ...string) (*Config, error) {
+	var fields map[string]interface{}
+	var loaded []string
+
+	for _, configPath := range paths {
+		if configPath == "" {
+			continue
+		}
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			continue
+		}
+
+		layer, err := readConfigLayer(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+
+		if fields == nil {
+			fields = layer
+		} else {
+			mergeFields(fields, layer, false)
+		}
+		loaded = append(loaded, configPath)
 	}
 
+	if fields == nil {
+		return nil, fmt.Errorf("no config file found in: %s", strings.Join(paths, ", "))
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, fmt.Errorf("failed to merge config files: %w", err)
 	}
+	config.sourcePaths = loaded
 
 	// Merge with defaults for missing values
 	defaultConfig := DefaultConfig()
 	mergeConfigs(&config, defaultConfig)
+	config.NormalizeBaseURLs()
 
 	return &config, nil
 }
 
+// readConfigLayer reads a config file for LoadConfigLayered as decoded JSON fields,
+// so that only the settings the file contains override earlier layers. The file is
+// also decoded into a Config, which reports parse errors and is migrated; migration
+// only renames default_provider and sets the version, which are copied back.
+func readConfigLayer(configPath string) (map[string]interface{}, error) {
+	layer, err := readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := layer.Migrate(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	jsonData, err := configJSON(configPath, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(jsonData, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if _, ok := fields["default_provider"]; ok {
+		fields["default_provider"] = layer.DefaultProvider
+	}
+	fields["version"] = layer.Version
+	return fields, nil
+}
+
+// mergeFields merges the decoded JSON fields of a later layer into fields. Objects
+// present in both are merged recursively and anything else in layer replaces the
+// earlier value. headers is set when the objects are header maps, whose names are
+// case-insensitive.
+func mergeFields(fields, layer map[string]interface{}, headers bool) {
+	for key, value := range layer {
+		if headers {
+			for existing := range fields {
+				if existing != key && strings.EqualFold(existing, key) {
+					delete(fields, existing)
+				}
+			}
+		}
+
+		earlier, earlierIsObject := fields[key].(map[string]interface{})
+		later, laterIsObject := value.(map[string]interface{})
+		if earlierIsObject && laterIsObject {
+			mergeFields(earlier, later, isHeadersKey(key))
+			continue
+		}
+		fields[key] = value
+	}
+}
+
+// LoadConfigDir loads every .json and .toml file directly inside dir, such as the
+// fragments in a conf.d directory, and layers them in lexical order of their names
+// as LoadConfigLayered does, so later files override earlier ones. Subdirectories are
+// ignored. Errors for a file that fails to parse name the file. YAML is not supported:
+// rather than skipping .yaml and .yml files silently, LoadConfigDir returns an error
+// naming them.
+func LoadConfigDir(dir string) (*Config, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+
+	var paths, unsupported []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".toml":
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		case ".yaml", ".yml":
+			unsupported = append(unsupported, entry.Name())
+		}
+	}
+	sort.Strings(paths)
+
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return nil, fmt.Errorf("unsupported config files in %s: %s (YAML is not supported; use .json or .toml)", dir, strings.Join(unsupported, ", "))
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .json or .toml config files found in %s", dir)
+	}
+	return LoadConfigLayered(paths...)
+}
+
+// readConfigFile reads and parses a config file without merging defaults
+func readConfigFile(configPath string) (*Config, error) {
+	return decodeConfigFile(configPath, false)
+}
+
+// decodeConfigFile is readConfigFile, rejecting unknown fields when strict is set
+func decodeConfigFile(configPath string, strict bool) (*Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	unmarshal := unmarshalConfigData
+	if strict {
+		unmarshal = unmarshalConfigDataStrict
+	}
+
+	var config Config
+	if err := unmarshal(configPath, data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	config.sourcePaths = []string{configPath}
+
+	return &config, nil
+}
+
+// SourcePath returns the file the config was loaded from, such as the path given to
+// LoadConfig or named by SMART_SUGGESTION_PROVIDER_FILE. For a layered config it is
+// the last file applied, whose settings take precedence. It is empty for configs
+// that were not loaded from a file.
+func (c *Config) SourcePath() string {
+	if len(c.sourcePaths) == 0 {
+		return ""
+	}
+	return c.sourcePaths[len(c.sourcePaths)-1]
+}
+
+// SourcePaths returns every file the config was loaded from, in the order they were
+// applied. LoadConfigLayered and LoadConfigDir list each file that existed.
+func (c *Config) SourcePaths() []string {
+	return slices.Clone(c.sourcePaths)
+}
+
 // LoadConfigFromEnv loads configuration from the path specified in SMART_SUGGESTION_PROVIDER_FILE
 // environment variable. If the environment variable is not set, returns an error.
 func LoadConfigFromEnv() (*Config, error) {
@@ -128,7 +666,8 @@ func LoadConfigFromEnv() (*Config, error) {
 	return LoadConfig(configPath)
 }
 
-// SaveConfig saves the configuration to the specified file path
+// SaveConfig saves the configuration to the specified file path. Paths ending in
+// .toml are written as TOML; anything else is written as JSON.
 func (c *Config) SaveConfig(configPath string) error {
 	if configPath == "" {
 		return fmt.Errorf("config file path is required")
@@ -140,7 +679,7 @@ func (c *Config) SaveConfig(configPath string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	data, err := marshalConfigData(configPath, c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -181,9 +720,105 @@ func (c *Config) GetProviderConfig(provider string) (*ProviderConfig, error) {
 			return nil, fmt.Errorf("DeepSeek configuration not found")
 		}
 		return c.DeepSeek, nil
+	case "mistral":
+		if c.Mistral == nil {
+			return nil, fmt.Errorf("Mistral configuration not found")
+		}
+		return c.Mistral, nil
+	case "openrouter":
+		if c.OpenRouter == nil {
+			return nil, fmt.Errorf("OpenRouter configuration not found")
+		}
+		return c.OpenRouter, nil
+	case "ollama":
+		if c.Ollama == nil {
+			return nil, fmt.Errorf("Ollama configuration not found")
+		}
+		return c.Ollama, nil
+	default:
+		if !isRegisteredProvider(provider) {
+			return nil, fmt.Errorf("unsupported provider: %s", provider)
+		}
+		if c.CustomProviders[provider] == nil {
+			return nil, fmt.Errorf("%s configuration not found", provider)
+		}
+		return c.CustomProviders[provider], nil
+	}
+}
+
+// SetProviderConfig sets the configuration for the specified provider.
+// For azure_openai, the Azure-specific fields of an existing configuration are preserved.
+func (c *Config) SetProviderConfig(provider string, pc *ProviderConfig) error {
+	if pc == nil {
+		return fmt.Errorf("provider configuration is required")
+	}
+
+	switch provider {
+	case "openai":
+		c.OpenAI = pc
+	case "openai_compatible":
+		c.OpenAICompatible = pc
+	case "azure_openai":
+		if c.AzureOpenAI == nil {
+			c.AzureOpenAI = &AzureOpenAIConfig{}
+		}
+		c.AzureOpenAI.ProviderConfig = *pc
+	case "anthropic":
+		c.Anthropic = pc
+	case "gemini":
+		c.Gemini = pc
+	case "deepseek":
+		c.DeepSeek = pc
+	case "mistral":
+		c.Mistral = pc
+	case "openrouter":
+		c.OpenRouter = pc
+	case "ollama":
+		c.Ollama = pc
 	default:
-		return nil, fmt.Errorf("unsupported provider: %s", provider)
+		if !isRegisteredProvider(provider) {
+			return fmt.Errorf("unsupported provider: %s", provider)
+		}
+		if c.CustomProviders == nil {
+			c.CustomProviders = map[string]*ProviderConfig{}
+		}
+		c.CustomProviders[provider] = pc
+	}
+
+	delete(c.defaulted, provider)
+	return nil
+}
+
+// GetModel returns the model to use for the specified provider, falling back to the
+// DefaultConfig model when none is configured. For azure_openai the deployment name
+// is the effective model.
+func (c *Config) GetModel(provider string) (string, error) {
+	if provider == "azure_openai" {
+		if c.AzureOpenAI == nil {
+			return "", fmt.Errorf("Azure OpenAI configuration not found")
+		}
+		if c.AzureOpenAI.DeploymentName == "" {
+			return "", fmt.Errorf("Azure OpenAI deployment name not configured")
+		}
+		return c.AzureOpenAI.DeploymentName, nil
+	}
+
+	if !isValidProvider(provider) {
+		return "", fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	if pc, err := c.GetProviderConfig(provider); err == nil && pc.Model != "" {
+		return pc.Model, nil
 	}
+
+	defaultProvider, err := DefaultConfig().GetProviderConfig(provider)
+	if err != nil {
+		return "", err
+	}
+	if defaultProvider.Model == "" {
+		return "", fmt.Errorf("no default model for provider: %s", provider)
+	}
+	return defaultProvider.Model, nil
 }
 
 // GetAzureOpenAIConfig returns the Azure OpenAI configuration
@@ -224,6 +859,22 @@ func (c *Config) GetAPIKey(provider string) (string, error) {
 		if c.DeepSeek != nil {
 			configKey = c.DeepSeek.APIKey
 		}
+	case "mistral":
+		if c.Mistral != nil {
+			configKey = c.Mistral.APIKey
+		}
+	case "openrouter":
+		if c.OpenRouter != nil {
+			configKey = c.OpenRouter.APIKey
+		}
+	case "ollama":
+		if c.Ollama != nil {
+			configKey = c.Ollama.APIKey
+		}
+	default:
+		if pc := c.CustomProviders[provider]; pc != nil && isRegisteredProvider(provider) {
+			configKey = pc.APIKey
+		}
 	}
 
 	// Return config key if available
@@ -234,6 +885,48 @@ func (c *Config) GetAPIKey(provider string) (string, error) {
 	return "", fmt.Errorf("%s API key not found in config file", provider)
 }
 
+// apiKeyEnvVars are the conventional environment variables holding each provider's API key
+var apiKeyEnvVars = map[string]string{
+	"openai":            "OPENAI_API_KEY",
+	"openai_compatible": "OPENAI_COMPATIBLE_API_KEY",
+	"azure_openai":      "AZURE_OPENAI_API_KEY",
+	"anthropic":         "ANTHROPIC_API_KEY",
+	"gemini":            "GEMINI_API_KEY",
+	"deepseek":          "DEEPSEEK_API_KEY",
+	"mistral":           "MISTRAL_API_KEY",
+	"openrouter":        "OPENROUTER_API_KEY",
+	"ollama":            "OLLAMA_API_KEY",
+}
+
+// APIKeyEnvVar returns the environment variable ResolveAPIKey reads for provider: the
+// provider's api_key_env when set, otherwise the conventional name
+func (c *Config) APIKeyEnvVar(provider string) string {
+	if pc := c.providerConfigs()[provider]; pc != nil && pc.APIKeyEnv != "" {
+		return pc.APIKeyEnv
+	}
+	if isRegisteredProvider(provider) {
+		return customAPIKeyEnvVar(provider)
+	}
+	return apiKeyEnvVars[provider]
+}
+
+// ResolveAPIKey returns the API key for provider from the config file, falling back to
+// the environment variable named by APIKeyEnvVar when the config has none
+func (c *Config) ResolveAPIKey(provider string) (string, error) {
+	if !isValidProvider(provider) {
+		return "", fmt.Errorf("unsupported provider: %s", provider)
+	}
+	if key, err := c.GetAPIKey(provider); err == nil {
+		return key, nil
+	}
+
+	envVar := c.APIKeyEnvVar(provider)
+	if key := os.Getenv(envVar); key != "" {
+		return key, nil
+	}
+	return "", fmt.Errorf("%s API key not found in config file or $%s", provider, envVar)
+}
+
 // mergeConfigs merges missing fields from defaultConfig into config
 func mergeConfigs(config, defaultConfig *Config) {
 	if config.DefaultProvider == "" {
@@ -245,49 +938,113 @@ func mergeConfigs(config, defaultConfig *Config) {
 		config.PrivacyFilter = defaultConfig.PrivacyFilter
 	}
 
+	if len(config.GlobalHeaders) == 0 {
+		config.GlobalHeaders = defaultConfig.GlobalHeaders
+	}
+
 	// Merge provider configs
 	if config.OpenAI == nil {
 		config.OpenAI = defaultConfig.OpenAI
+		config.markDefaulted("openai")
 	} else {
 		mergeProviderConfig(config.OpenAI, defaultConfig.OpenAI)
 	}
 
 	if config.OpenAICompatible == nil {
 		config.OpenAICompatible = defaultConfig.OpenAICompatible
+		config.markDefaulted("openai_compatible")
 	} else {
 		mergeProviderConfig(config.OpenAICompatible, defaultConfig.OpenAICompatible)
 	}
 
 	if config.AzureOpenAI == nil {
 		config.AzureOpenAI = defaultConfig.AzureOpenAI
+		config.markDefaulted("azure_openai")
 	} else {
 		mergeProviderConfig(&config.AzureOpenAI.ProviderConfig, &defaultConfig.AzureOpenAI.ProviderConfig)
 		if config.AzureOpenAI.APIVersion == "" {
 			config.AzureOpenAI.APIVersion = defaultConfig.AzureOpenAI.APIVersion
 		}
+		if config.AzureOpenAI.ResourceName == "" {
+			config.AzureOpenAI.ResourceName = defaultConfig.AzureOpenAI.ResourceName
+		}
+		if config.AzureOpenAI.DeploymentName == "" {
+			config.AzureOpenAI.DeploymentName = defaultConfig.AzureOpenAI.DeploymentName
+		}
 	}
 
 	if config.Anthropic == nil {
 		config.Anthropic = defaultConfig.Anthropic
+		config.markDefaulted("anthropic")
 	} else {
 		mergeProviderConfig(config.Anthropic, defaultConfig.Anthropic)
 	}
 
 	if config.Gemini == nil {
 		config.Gemini = defaultConfig.Gemini
+		config.markDefaulted("gemini")
 	} else {
 		mergeProviderConfig(config.Gemini, defaultConfig.Gemini)
 	}
 
 	if config.DeepSeek == nil {
 		config.DeepSeek = defaultConfig.DeepSeek
+		config.markDefaulted("deepseek")
 	} else {
 		mergeProviderConfig(config.DeepSeek, defaultConfig.DeepSeek)
 	}
+
+	if config.Mistral == nil {
+		config.Mistral = defaultConfig.Mistral
+		config.markDefaulted("mistral")
+	} else {
+		mergeProviderConfig(config.Mistral, defaultConfig.Mistral)
+	}
+
+	if config.OpenRouter == nil {
+		config.OpenRouter = defaultConfig.OpenRouter
+		config.markDefaulted("openrouter")
+	} else {
+		mergeProviderConfig(config.OpenRouter, defaultConfig.OpenRouter)
+	}
+
+	if config.Ollama == nil {
+		config.Ollama = defaultConfig.Ollama
+		config.markDefaulted("ollama")
+	} else {
+		mergeProviderConfig(config.Ollama, defaultConfig.Ollama)
+	}
+
+	for name, defaultProvider := range defaultConfig.CustomProviders {
+		if config.CustomProviders == nil {
+			config.CustomProviders = map[string]*ProviderConfig{}
+		}
+		if provider := config.CustomProviders[name]; provider == nil {
+			config.CustomProviders[name] = defaultProvider
+			config.markDefaulted(name)
+		} else {
+			mergeProviderConfig(provider, defaultProvider)
+		}
+	}
+}
+
+// markDefaulted records that the provider's block was filled in from the defaults
+// rather than configured, so ListConfiguredProviders skips it
+func (c *Config) markDefaulted(provider string) {
+	if c.defaulted == nil {
+		c.defaulted = map[string]bool{}
+	}
+	c.defaulted[provider] = true
 }
 
 // mergeProviderConfig merges missing fields from defaultProvider into provider
 func mergeProviderConfig(provider, defaultProvider *ProviderConfig) {
+	if provider.APIKey == "" {
+		provider.APIKey = defaultProvider.APIKey
+	}
+	if provider.APIKeyEnv == "" {
+		provider.APIKeyEnv = defaultProvider.APIKeyEnv
+	}
 	if provider.BaseURL == "" {
 		provider.BaseURL = defaultProvider.BaseURL
 	}
@@ -297,6 +1054,178 @@ func mergeProviderConfig(provider, defaultProvider *ProviderConfig) {
 	if provider.APIVersion == "" {
 		provider.APIVersion = defaultProvider.APIVersion
 	}
+	if provider.Organization == "" {
+		provider.Organization = defaultProvider.Organization
+	}
+	if provider.Project == "" {
+		provider.Project = defaultProvider.Project
+	}
+	if provider.EmbeddingBaseURL == "" {
+		provider.EmbeddingBaseURL = defaultProvider.EmbeddingBaseURL
+	}
+	if provider.SystemPrompt == "" {
+		provider.SystemPrompt = defaultProvider.SystemPrompt
+	}
+	if len(provider.ExtraBody) == 0 {
+		provider.ExtraBody = defaultProvider.ExtraBody
+	}
+	if len(provider.Headers) == 0 {
+		provider.Headers = defaultProvider.Headers
+	}
+	if provider.Enabled == nil {
+		provider.Enabled = defaultProvider.Enabled
+	}
+	if provider.Stream == nil {
+		provider.Stream = defaultProvider.Stream
+	}
+	if provider.Retry == nil {
+		provider.Retry = defaultProvider.Retry
+	} else if defaultProvider.Retry != nil {
+		mergeRetryConfig(provider.Retry, defaultProvider.Retry)
+	}
+}
+
+// mergeRetryConfig merges missing fields from defaultRetry into retry
+func mergeRetryConfig(retry, defaultRetry *RetryConfig) {
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = defaultRetry.MaxAttempts
+	}
+	if retry.InitialBackoffMs == 0 {
+		retry.InitialBackoffMs = defaultRetry.InitialBackoffMs
+	}
+	if retry.MaxBackoffMs == 0 {
+		retry.MaxBackoffMs = defaultRetry.MaxBackoffMs
+	}
+	if retry.Multiplier == 0 {
+		retry.Multiplier = defaultRetry.Multiplier
+	}
+}
+
+// NormalizeBaseURL trims a single trailing slash from baseURL and, unless requireScheme
+// is set, prepends https:// to URLs without a scheme
+func NormalizeBaseURL(baseURL string, requireScheme bool) string {
+	if baseURL == "" {
+		return baseURL
+	}
+
+	normalized := strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	if !requireScheme && !strings.Contains(normalized, "://") {
+		normalized = "https://" + normalized
+	}
+	return normalized
+}
+
+// NormalizeBaseURLs normalizes the base URL of every configured provider
+func (c *Config) NormalizeBaseURLs() {
+	for _, pc := range c.providerConfigs() {
+		pc.BaseURL = NormalizeBaseURL(pc.BaseURL, c.BaseURLRequireScheme)
+		pc.EmbeddingBaseURL = NormalizeBaseURL(pc.EmbeddingBaseURL, c.BaseURLRequireScheme)
+	}
+}
+
+// SystemPrompt returns the system prompt configured for provider, or "" when the
+// provider is not configured or sets none
+func (c *Config) SystemPrompt(provider string) string {
+	if pc, ok := c.providerConfigs()[provider]; ok {
+		return pc.SystemPrompt
+	}
+	return ""
+}
+
+// providerConfigs returns the configured providers keyed by name, including the embedded Azure config
+func (c *Config) providerConfigs() map[string]*ProviderConfig {
+	configs := map[string]*ProviderConfig{}
+	if c.OpenAI != nil {
+		configs["openai"] = c.OpenAI
+	}
+	if c.OpenAICompatible != nil {
+		configs["openai_compatible"] = c.OpenAICompatible
+	}
+	if c.AzureOpenAI != nil {
+		configs["azure_openai"] = &c.AzureOpenAI.ProviderConfig
+	}
+	if c.Anthropic != nil {
+		configs["anthropic"] = c.Anthropic
+	}
+	if c.Gemini != nil {
+		configs["gemini"] = c.Gemini
+	}
+	if c.DeepSeek != nil {
+		configs["deepseek"] = c.DeepSeek
+	}
+	if c.Mistral != nil {
+		configs["mistral"] = c.Mistral
+	}
+	if c.OpenRouter != nil {
+		configs["openrouter"] = c.OpenRouter
+	}
+	if c.Ollama != nil {
+		configs["ollama"] = c.Ollama
+	}
+	for name, pc := range c.CustomProviders {
+		if pc != nil && isRegisteredProvider(name) {
+			configs[name] = pc
+		}
+	}
+	return configs
+}
+
+// IsEnabled reports whether the provider is configured and not disabled
+func (p *ProviderConfig) IsEnabled() bool {
+	return p != nil && (p.Enabled == nil || *p.Enabled)
+}
+
+// StreamEnabled reports whether responses should be requested as an SSE stream
+func (p *ProviderConfig) StreamEnabled() bool {
+	return p == nil || p.Stream == nil || *p.Stream
+}
+
+// ListConfiguredProviders returns the names of the providers that have a configuration
+// block and are enabled, in the order of Providers followed by registered providers
+// in the order of RegisteredProviders. Blocks filled in from the defaults when the
+// config was loaded are skipped, except for the default provider's.
+func (c *Config) ListConfiguredProviders() []string {
+	configs := c.providerConfigs()
+	listed := func(name string) bool {
+		return configs[name].IsEnabled() && (!c.defaulted[name] || name == c.DefaultProvider)
+	}
+
+	var names []string
+	for _, p := range Providers() {
+		if listed(p.String()) {
+			names = append(names, p.String())
+		}
+	}
+	for _, name := range RegisteredProviders() {
+		if listed(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// EffectiveHeaders returns the extra HTTP headers to send to provider: GlobalHeaders
+// merged with the provider's own Headers, with the provider's value winning when both
+// set the same header. Names are compared case-insensitively and returned in canonical
+// form. The result is a new map, or nil when no headers are configured.
+func (c *Config) EffectiveHeaders(provider string) map[string]string {
+	var providerHeaders map[string]string
+	if pc := c.providerConfigs()[provider]; pc != nil {
+		providerHeaders = pc.Headers
+	}
+
+	if len(c.GlobalHeaders) == 0 && len(providerHeaders) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(c.GlobalHeaders)+len(providerHeaders))
+	for name, value := range c.GlobalHeaders {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	for name, value := range providerHeaders {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	return headers
 }
 
 // GetPrivacyFilterConfig returns the privacy filter configuration with defaults if not configured
@@ -307,6 +1236,14 @@ func (c *Config) GetPrivacyFilterConfig() *privacy.FilterConfig {
 	return c.PrivacyFilter
 }
 
+// GetEmbeddingBaseURL returns the base URL for embedding requests, falling back to BaseURL
+func (p *ProviderConfig) GetEmbeddingBaseURL() string {
+	if p.EmbeddingBaseURL != "" {
+		return p.EmbeddingBaseURL
+	}
+	return p.BaseURL
+}
+
 // MergeExtraBody merges the extra_body configuration into a request map.
 // It returns a new map with all fields from the original request plus any extra fields.
 // Extra body fields will override request fields if there's a conflict.
@@ -0,0 +1,1387 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yetone/smart-suggestion/pkg/privacy"
+)
+
+func TestSetProviderConfig(t *testing.T) {
+	providers := []string{"openai", "openai_compatible", "anthropic", "gemini", "deepseek", "mistral", "openrouter", "ollama"}
+
+	for _, provider := range providers {
+		t.Run(provider, func(t *testing.T) {
+			cfg := &Config{}
+			pc := &ProviderConfig{APIKey: "test-key", Model: "test-model"}
+
+			if err := cfg.SetProviderConfig(provider, pc); err != nil {
+				t.Fatalf("SetProviderConfig(%q) returned error: %v", provider, err)
+			}
+
+			got, err := cfg.GetProviderConfig(provider)
+			if err != nil {
+				t.Fatalf("GetProviderConfig(%q) returned error: %v", provider, err)
+			}
+			if got != pc {
+				t.Errorf("GetProviderConfig(%q) did not return the config that was set", provider)
+			}
+		})
+	}
+}
+
+func TestSetProviderConfig_AzureOpenAI(t *testing.T) {
+	cfg := &Config{
+		AzureOpenAI: &AzureOpenAIConfig{
+			ResourceName:   "my-resource",
+			DeploymentName: "my-deployment",
+		},
+	}
+
+	if err := cfg.SetProviderConfig("azure_openai", &ProviderConfig{APIKey: "azure-key"}); err != nil {
+		t.Fatalf("SetProviderConfig returned error: %v", err)
+	}
+
+	if cfg.AzureOpenAI.APIKey != "azure-key" {
+		t.Errorf("expected API key to be set, got %q", cfg.AzureOpenAI.APIKey)
+	}
+	if cfg.AzureOpenAI.ResourceName != "my-resource" || cfg.AzureOpenAI.DeploymentName != "my-deployment" {
+		t.Errorf("expected Azure-specific fields to be preserved, got %+v", cfg.AzureOpenAI)
+	}
+
+	empty := &Config{}
+	if err := empty.SetProviderConfig("azure_openai", &ProviderConfig{APIKey: "azure-key"}); err != nil {
+		t.Fatalf("SetProviderConfig returned error: %v", err)
+	}
+	if empty.AzureOpenAI == nil || empty.AzureOpenAI.APIKey != "azure-key" {
+		t.Errorf("expected Azure OpenAI config to be created, got %+v", empty.AzureOpenAI)
+	}
+}
+
+func TestSetProviderConfig_UnknownProvider(t *testing.T) {
+	cfg := &Config{}
+	err := cfg.SetProviderConfig("unknown", &ProviderConfig{})
+	if err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+	if !strings.Contains(err.Error(), "unsupported provider") {
+		t.Errorf("expected unsupported provider error, got: %v", err)
+	}
+}
+
+func TestConfigBuilder(t *testing.T) {
+	cfg, err := NewConfigBuilder().
+		WithProvider("openai", &ProviderConfig{APIKey: "key", Model: "gpt-4o"}).
+		WithProvider("anthropic", &ProviderConfig{APIKey: "key", Model: "claude-3-5-sonnet-20241022", APIVersion: "2023-06-01"}).
+		WithDefaultProvider("anthropic").
+		Build()
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+
+	if cfg.DefaultProvider != "anthropic" {
+		t.Errorf("expected default provider anthropic, got %q", cfg.DefaultProvider)
+	}
+	if cfg.OpenAI == nil || cfg.OpenAI.Model != "gpt-4o" {
+		t.Errorf("expected OpenAI config to be set, got %+v", cfg.OpenAI)
+	}
+}
+
+func TestConfigBuilder_Errors(t *testing.T) {
+	if _, err := NewConfigBuilder().WithProvider("unknown", &ProviderConfig{}).Build(); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+
+	if _, err := NewConfigBuilder().WithDefaultProvider("unknown").Build(); err == nil {
+		t.Error("expected validation error for invalid default provider")
+	}
+}
+
+func writeTestConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+}
+
+func TestLoadConfigLayered(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "config.json")
+	projectPath := filepath.Join(dir, ".smart-suggestion.json")
+
+	writeTestConfig(t, globalPath, `{
+  "default_provider": "anthropic",
+  "openai": {"api_key": "global-openai-key", "model": "gpt-4o"},
+  "anthropic": {"api_key": "global-anthropic-key", "model": "claude-3-5-haiku-20241022"}
+}`)
+	writeTestConfig(t, projectPath, `{
+  "anthropic": {"model": "claude-3-7-sonnet-20250219"}
+}`)
+
+	cfg, err := LoadConfigLayered(globalPath, filepath.Join(dir, "missing.json"), projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigLayered returned error: %v", err)
+	}
+
+	if cfg.DefaultProvider != "anthropic" {
+		t.Errorf("expected default provider to be inherited, got %q", cfg.DefaultProvider)
+	}
+	if cfg.Anthropic.Model != "claude-3-7-sonnet-20250219" {
+		t.Errorf("expected project model override, got %q", cfg.Anthropic.Model)
+	}
+	if cfg.Anthropic.APIKey != "global-anthropic-key" {
+		t.Errorf("expected API key to be inherited, got %q", cfg.Anthropic.APIKey)
+	}
+	if cfg.Anthropic.BaseURL != "https://api.anthropic.com" {
+		t.Errorf("expected default base URL, got %q", cfg.Anthropic.BaseURL)
+	}
+	if cfg.OpenAI.APIKey != "global-openai-key" || cfg.OpenAI.Model != "gpt-4o" {
+		t.Errorf("expected OpenAI config to be inherited, got %+v", cfg.OpenAI)
+	}
+}
+
+func TestLoadConfigLayered_DeepMerge(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "config.json")
+	projectPath := filepath.Join(dir, ".smart-suggestion.json")
+
+	writeTestConfig(t, globalPath, `{
+  "global_headers": {"X-Team": "platform", "X-Trace": "on"},
+  "openai": {"api_key": "global-key", "headers": {"X-Org": "acme"}},
+  "privacy_filter": {"enabled": true, "level": 1, "custom_patterns": ["acme-[0-9]+"], "literal_denylist": ["hunter2hunter2"]}
+}`)
+	writeTestConfig(t, projectPath, `{
+  "global_headers": {"x-trace": "off"},
+  "openai": {"headers": {"X-Project": "web"}},
+  "privacy_filter": {"level": 3}
+}`)
+
+	cfg, err := LoadConfigLayered(globalPath, projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigLayered returned error: %v", err)
+	}
+
+	pf := cfg.PrivacyFilter
+	if pf.Level != 3 || !pf.Enabled {
+		t.Errorf("expected the project level over the global filter, got level=%d enabled=%v", pf.Level, pf.Enabled)
+	}
+	if !reflect.DeepEqual(pf.CustomPatterns, []string{"acme-[0-9]+"}) || !reflect.DeepEqual(pf.LiteralDenylist, []string{"hunter2hunter2"}) {
+		t.Errorf("expected global patterns and denylist to be inherited, got %v and %v", pf.CustomPatterns, pf.LiteralDenylist)
+	}
+	if !reflect.DeepEqual(cfg.GlobalHeaders, map[string]string{"X-Team": "platform", "x-trace": "off"}) {
+		t.Errorf("expected global headers merged by name, got %v", cfg.GlobalHeaders)
+	}
+	if !reflect.DeepEqual(cfg.OpenAI.Headers, map[string]string{"X-Org": "acme", "X-Project": "web"}) || cfg.OpenAI.APIKey != "global-key" {
+		t.Errorf("expected provider headers merged and the key kept, got %+v", cfg.OpenAI)
+	}
+}
+
+func TestLoadConfigLayered_SecuritySettings(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "config.json")
+	projectPath := filepath.Join(dir, ".smart-suggestion.json")
+	writeTestConfig(t, globalPath, `{"require_https": true, "base_url_require_scheme": true}`)
+
+	writeTestConfig(t, projectPath, `{"openai": {"model": "gpt-4o"}}`)
+	cfg, err := LoadConfigLayered(globalPath, projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigLayered returned error: %v", err)
+	}
+	if !cfg.RequireHTTPS || !cfg.BaseURLRequireScheme {
+		t.Errorf("expected global security settings to survive a project layer, got require_https=%v base_url_require_scheme=%v", cfg.RequireHTTPS, cfg.BaseURLRequireScheme)
+	}
+
+	writeTestConfig(t, projectPath, `{"require_https": false}`)
+	cfg, err = LoadConfigLayered(globalPath, projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigLayered returned error: %v", err)
+	}
+	if cfg.RequireHTTPS || !cfg.BaseURLRequireScheme {
+		t.Errorf("expected an explicit false to override only require_https, got require_https=%v base_url_require_scheme=%v", cfg.RequireHTTPS, cfg.BaseURLRequireScheme)
+	}
+}
+
+func TestLoadConfigLayered_NoFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadConfigLayered(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")); err == nil {
+		t.Error("expected error when no config files exist")
+	}
+}
+
+func TestLoadConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, filepath.Join(dir, "10-base.json"), `{
+  "default_provider": "anthropic",
+  "anthropic": {"api_key": "base-key", "model": "claude-3-5-haiku-20241022"}
+}`)
+	writeTestConfig(t, filepath.Join(dir, "20-override.json"), `{
+  "anthropic": {"model": "claude-3-7-sonnet-20250219"}
+}`)
+	writeTestConfig(t, filepath.Join(dir, "README.md"), "not a config")
+	if err := os.Mkdir(filepath.Join(dir, "nested.json"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfigDir(dir)
+	if err != nil {
+		t.Fatalf("LoadConfigDir returned error: %v", err)
+	}
+	if cfg.Anthropic.Model != "claude-3-7-sonnet-20250219" {
+		t.Errorf("expected second fragment to override model, got %q", cfg.Anthropic.Model)
+	}
+	if cfg.Anthropic.APIKey != "base-key" || cfg.DefaultProvider != "anthropic" {
+		t.Errorf("expected first fragment values to be kept, got %+v", cfg.Anthropic)
+	}
+
+	writeTestConfig(t, filepath.Join(dir, "30-broken.json"), `{"anthropic": `)
+	_, err = LoadConfigDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "30-broken.json") {
+		t.Errorf("expected parse error naming the file, got %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "30-broken.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestConfig(t, filepath.Join(dir, "40-override.yaml"), "anthropic:\n  model: claude-3-5-haiku-latest\n")
+	writeTestConfig(t, filepath.Join(dir, "50-extra.yml"), "default_provider: openai\n")
+	_, err = LoadConfigDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "40-override.yaml, 50-extra.yml") {
+		t.Errorf("expected an error naming the YAML files, got %v", err)
+	}
+
+	if _, err := LoadConfigDir(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without config files")
+	}
+}
+
+func TestConfigClone(t *testing.T) {
+	original := DefaultConfig()
+	original.OpenAI.ExtraBody = map[string]interface{}{
+		"options": map[string]interface{}{"temperature": 0.2},
+	}
+	original.AzureOpenAI.DeploymentName = "my-deployment"
+	original.PrivacyFilter.CustomPatterns = []string{`secret_\w+`}
+
+	clone := original.Clone()
+	clone.OpenAI.Model = "gpt-4o"
+	clone.OpenAI.ExtraBody["options"].(map[string]interface{})["temperature"] = 0.9
+	clone.AzureOpenAI.DeploymentName = "other-deployment"
+	clone.PrivacyFilter.CustomPatterns[0] = `changed`
+
+	if original.OpenAI.Model != "gpt-4o-mini" {
+		t.Errorf("expected original OpenAI model to be unchanged, got %q", original.OpenAI.Model)
+	}
+	if original.OpenAI.ExtraBody["options"].(map[string]interface{})["temperature"] != 0.2 {
+		t.Error("expected original extra_body to be unchanged")
+	}
+	if original.AzureOpenAI.DeploymentName != "my-deployment" {
+		t.Errorf("expected original Azure deployment to be unchanged, got %q", original.AzureOpenAI.DeploymentName)
+	}
+	if original.PrivacyFilter.CustomPatterns[0] != `secret_\w+` {
+		t.Error("expected original custom patterns to be unchanged")
+	}
+}
+
+func TestNormalizeBaseURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		baseURL       string
+		requireScheme bool
+		expected      string
+	}{
+		{"trailing slash", "https://api.openai.com/", false, "https://api.openai.com"},
+		{"missing scheme", "api.openai.com", false, "https://api.openai.com"},
+		{"missing scheme with trailing slash", "api.openai.com/", false, "https://api.openai.com"},
+		{"missing scheme when required", "api.openai.com", true, "api.openai.com"},
+		{"already correct", "http://localhost:11434", false, "http://localhost:11434"},
+		{"empty", "", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeBaseURL(tt.baseURL, tt.requireScheme); got != tt.expected {
+				t.Errorf("NormalizeBaseURL(%q, %v) = %q, want %q", tt.baseURL, tt.requireScheme, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_NormalizesBaseURLs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeTestConfig(t, path, `{
+  "openai": {"base_url": "https://api.openai.com/"},
+  "deepseek": {"base_url": "api.deepseek.com"}
+}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	if cfg.OpenAI.BaseURL != "https://api.openai.com" {
+		t.Errorf("expected trailing slash to be trimmed, got %q", cfg.OpenAI.BaseURL)
+	}
+	if cfg.DeepSeek.BaseURL != "https://api.deepseek.com" {
+		t.Errorf("expected https scheme to be prepended, got %q", cfg.DeepSeek.BaseURL)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected normalized config to validate, got: %v", err)
+	}
+}
+
+func TestGetModel(t *testing.T) {
+	cfg := &Config{
+		OpenAI:    &ProviderConfig{Model: "gpt-4o"},
+		Anthropic: &ProviderConfig{},
+	}
+
+	if model, err := cfg.GetModel("openai"); err != nil || model != "gpt-4o" {
+		t.Errorf("GetModel(openai) = %q, %v; want configured model", model, err)
+	}
+
+	if model, err := cfg.GetModel("anthropic"); err != nil || model != "claude-3-5-sonnet-20241022" {
+		t.Errorf("GetModel(anthropic) = %q, %v; want default model", model, err)
+	}
+
+	if model, err := cfg.GetModel("gemini"); err != nil || model != "gemini-2.5-flash" {
+		t.Errorf("GetModel(gemini) = %q, %v; want default model for unconfigured provider", model, err)
+	}
+
+	if _, err := cfg.GetModel("unknown"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+func TestGetModel_AzureOpenAI(t *testing.T) {
+	cfg := &Config{
+		AzureOpenAI: &AzureOpenAIConfig{
+			ProviderConfig: ProviderConfig{Model: "gpt-4o"},
+			DeploymentName: "my-gpt4o-deployment",
+		},
+	}
+
+	if model, err := cfg.GetModel("azure_openai"); err != nil || model != "my-gpt4o-deployment" {
+		t.Errorf("GetModel(azure_openai) = %q, %v; want deployment name", model, err)
+	}
+
+	cfg.AzureOpenAI.DeploymentName = ""
+	if _, err := cfg.GetModel("azure_openai"); err == nil {
+		t.Error("expected error when Azure deployment name is not configured")
+	}
+}
+
+func TestProviderConfig_OrganizationAndProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := DefaultConfig()
+	cfg.OpenAI.Organization = "org-123"
+	cfg.OpenAI.Project = "proj_456"
+	if err := cfg.SaveConfig(path); err != nil {
+		t.Fatalf("SaveConfig returned error: %v", err)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if loaded.OpenAI.Organization != "org-123" || loaded.OpenAI.Project != "proj_456" {
+		t.Errorf("expected organization and project to round-trip, got %+v", loaded.OpenAI)
+	}
+
+	provider := &ProviderConfig{Project: "proj_override"}
+	mergeProviderConfig(provider, &ProviderConfig{Organization: "org-default", Project: "proj_default"})
+	if provider.Organization != "org-default" {
+		t.Errorf("expected organization to be merged, got %q", provider.Organization)
+	}
+	if provider.Project != "proj_override" {
+		t.Errorf("expected project to be kept, got %q", provider.Project)
+	}
+}
+
+func TestLoadConfigRaw(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeTestConfig(t, path, `{"openai": {"api_key": "sk-test"}}`)
+
+	cfg, err := LoadConfigRaw(path)
+	if err != nil {
+		t.Fatalf("LoadConfigRaw returned error: %v", err)
+	}
+
+	if cfg.OpenAI == nil || cfg.OpenAI.APIKey != "sk-test" {
+		t.Fatalf("expected OpenAI config to be loaded, got %+v", cfg.OpenAI)
+	}
+	if cfg.OpenAI.BaseURL != "" || cfg.OpenAI.Model != "" {
+		t.Errorf("expected OpenAI defaults not to be merged, got %+v", cfg.OpenAI)
+	}
+	if cfg.Anthropic != nil || cfg.Gemini != nil || cfg.AzureOpenAI != nil || cfg.Ollama != nil {
+		t.Error("expected unspecified providers to be nil")
+	}
+	if cfg.DefaultProvider != "" || cfg.PrivacyFilter != nil {
+		t.Error("expected general settings not to be merged")
+	}
+
+	if _, err := LoadConfigRaw(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestDefaultConfigFor(t *testing.T) {
+	providers := []string{"openai", "openai_compatible", "azure_openai", "anthropic", "gemini", "deepseek", "mistral", "openrouter", "ollama"}
+
+	for _, provider := range providers {
+		t.Run(provider, func(t *testing.T) {
+			cfg, err := DefaultConfigFor(provider)
+			if err != nil {
+				t.Fatalf("DefaultConfigFor returned error: %v", err)
+			}
+
+			if cfg.DefaultProvider != provider {
+				t.Errorf("expected default provider %q, got %q", provider, cfg.DefaultProvider)
+			}
+
+			configs := cfg.providerConfigs()
+			if _, ok := configs[provider]; !ok {
+				t.Errorf("expected %s config to be populated", provider)
+			}
+			if len(configs) != 1 {
+				t.Errorf("expected only %s to be populated, got %d providers", provider, len(configs))
+			}
+		})
+	}
+
+	cfg, _ := DefaultConfigFor("anthropic")
+	if cfg.Anthropic.Model != DefaultConfig().Anthropic.Model {
+		t.Errorf("expected Anthropic default model, got %q", cfg.Anthropic.Model)
+	}
+
+	if _, err := DefaultConfigFor("unknown"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+func TestMinimalDefaultConfig(t *testing.T) {
+	cfg := MinimalDefaultConfig()
+	defaults := DefaultConfig()
+
+	if cfg.DefaultProvider != defaults.DefaultProvider {
+		t.Errorf("expected default provider %q, got %q", defaults.DefaultProvider, cfg.DefaultProvider)
+	}
+	if cfg.OpenAI == nil {
+		t.Fatal("expected OpenAI config to be populated")
+	}
+
+	others := map[string]*ProviderConfig{
+		"openai_compatible": cfg.OpenAICompatible,
+		"anthropic":         cfg.Anthropic,
+		"gemini":            cfg.Gemini,
+		"deepseek":          cfg.DeepSeek,
+		"mistral":           cfg.Mistral,
+		"openrouter":        cfg.OpenRouter,
+		"ollama":            cfg.Ollama,
+	}
+	for name, pc := range others {
+		if pc != nil {
+			t.Errorf("expected %s config to be nil", name)
+		}
+	}
+	if cfg.AzureOpenAI != nil {
+		t.Error("expected azure_openai config to be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected minimal config to validate, got: %v", err)
+	}
+}
+
+func TestProviderConfig_EmbeddingBaseURL(t *testing.T) {
+	pc := &ProviderConfig{BaseURL: "https://chat.example.com"}
+	if got := pc.GetEmbeddingBaseURL(); got != "https://chat.example.com" {
+		t.Errorf("expected fallback to base_url, got %q", got)
+	}
+
+	pc.EmbeddingBaseURL = "https://embeddings.example.com"
+	if got := pc.GetEmbeddingBaseURL(); got != "https://embeddings.example.com" {
+		t.Errorf("expected embedding_base_url, got %q", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeTestConfig(t, path, `{"openai": {"api_key": "sk-test", "embedding_base_url": "embeddings.example.com/"}}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.OpenAI.EmbeddingBaseURL != "https://embeddings.example.com" {
+		t.Errorf("expected embedding_base_url to be loaded and normalized, got %q", cfg.OpenAI.EmbeddingBaseURL)
+	}
+	if cfg.OpenAI.BaseURL != "https://api.openai.com" {
+		t.Errorf("expected base_url default to be merged independently, got %q", cfg.OpenAI.BaseURL)
+	}
+}
+
+func TestSaveAndLoadConfig_TOML(t *testing.T) {
+	cfg := DefaultConfig()
+	for name, pc := range cfg.providerConfigs() {
+		pc.APIKey = name + "-key"
+		pc.Organization = "org-" + name
+	}
+	cfg.OpenAI.EmbeddingBaseURL = "https://embeddings.example.com"
+	cfg.OpenAI.ExtraBody = map[string]interface{}{"temperature": 0.2, "max_tokens": float64(256)}
+	cfg.AzureOpenAI.ResourceName = "my-resource"
+	cfg.AzureOpenAI.DeploymentName = "gpt-4o"
+	cfg.DefaultProvider = "anthropic"
+	cfg.PrivacyFilter.Level = privacy.FilterLevelStrict
+	cfg.PrivacyFilter.CustomPatterns = []string{`internal-[0-9]+`}
+	cfg.PrivacyFilter.CustomPatternSpecs = []privacy.CustomPatternSpec{{Pattern: "acme", WordBoundary: true}}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := cfg.SaveConfig(path); err != nil {
+		t.Fatalf("SaveConfig returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read saved config: %v", err)
+	}
+	if !strings.Contains(string(data), "[anthropic]") || !strings.Contains(string(data), `api_key = "anthropic-key"`) {
+		t.Errorf("expected provider tables in TOML output, got:\n%s", data)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	if loaded.SourcePath() != path {
+		t.Errorf("expected source path %s, got %q", path, loaded.SourcePath())
+	}
+	loaded.sourcePaths = nil
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("expected round trip to preserve config\nsaved:  %+v\nloaded: %+v", cfg, loaded)
+	}
+}
+
+func TestLoadConfig_TOMLMergesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestConfig(t, path, `default_provider = "openai"
+
+[openai]
+api_key = "sk-test"
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	if cfg.OpenAI.APIKey != "sk-test" {
+		t.Errorf("expected api_key from TOML, got %q", cfg.OpenAI.APIKey)
+	}
+	if cfg.OpenAI.BaseURL != DefaultConfig().OpenAI.BaseURL || cfg.Anthropic == nil || cfg.PrivacyFilter == nil {
+		t.Errorf("expected defaults to be merged as for JSON, got %+v", cfg)
+	}
+}
+
+// chdirTemp switches into a fresh temporary directory for the duration of the test
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestGetConfigSearchPaths(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	envFile := filepath.Join(t.TempDir(), "custom.json")
+	cwd := chdirTemp(t)
+
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("SMART_SUGGESTION_PROVIDER_FILE", envFile)
+
+	paths := GetConfigSearchPaths()
+	expected := []string{
+		envFile,
+		filepath.Join(xdg, "smart-suggestion", "config.json"),
+		filepath.Join(home, ".config", "smart-suggestion", "config.json"),
+		filepath.Join(cwd, ".smart-suggestion.json"),
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected %d paths, got %v", len(expected), paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("path %d: expected %q, got %q", i, expected[i], paths[i])
+		}
+	}
+
+	// Unset variables are skipped, and an XDG dir equal to ~/.config is listed once
+	t.Setenv("SMART_SUGGESTION_PROVIDER_FILE", "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	paths = GetConfigSearchPaths()
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 paths after dedup, got %v", paths)
+	}
+	if paths[0] != filepath.Join(home, ".config", "smart-suggestion", "config.json") {
+		t.Errorf("expected home config first, got %q", paths[0])
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	cwd := chdirTemp(t)
+
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("SMART_SUGGESTION_PROVIDER_FILE", "")
+
+	if _, err := FindConfig(); err == nil {
+		t.Error("expected error when no config file exists")
+	}
+
+	projectPath := filepath.Join(cwd, ".smart-suggestion.json")
+	writeTestConfig(t, projectPath, `{}`)
+	if path, err := FindConfig(); err != nil || filepath.Base(path) != ".smart-suggestion.json" {
+		t.Errorf("expected project config, got %q (err: %v)", path, err)
+	}
+
+	xdgPath := filepath.Join(xdg, "smart-suggestion", "config.json")
+	if err := os.MkdirAll(filepath.Dir(xdgPath), 0700); err != nil {
+		t.Fatalf("failed to create XDG config dir: %v", err)
+	}
+	writeTestConfig(t, xdgPath, `{}`)
+	if path, err := FindConfig(); err != nil || path != xdgPath {
+		t.Errorf("expected XDG config %q, got %q (err: %v)", xdgPath, path, err)
+	}
+
+	envPath := filepath.Join(t.TempDir(), "custom.json")
+	writeTestConfig(t, envPath, `{}`)
+	t.Setenv("SMART_SUGGESTION_PROVIDER_FILE", envPath)
+	if path, err := FindConfig(); err != nil || path != envPath {
+		t.Errorf("expected env config %q, got %q (err: %v)", envPath, path, err)
+	}
+
+	// A directory at a candidate location is not a config file
+	t.Setenv("SMART_SUGGESTION_PROVIDER_FILE", t.TempDir())
+	if path, err := FindConfig(); err != nil || path != xdgPath {
+		t.Errorf("expected directories to be skipped, got %q (err: %v)", path, err)
+	}
+}
+
+func TestEffectiveHeaders(t *testing.T) {
+	cfg := &Config{
+		GlobalHeaders: map[string]string{
+			"Proxy-Authorization": "Basic global",
+			"x-trace-id":          "global-trace",
+		},
+		OpenAI: &ProviderConfig{Headers: map[string]string{
+			"X-Trace-Id": "openai-trace",
+			"X-Team":     "infra",
+		}},
+		Anthropic: &ProviderConfig{},
+		AzureOpenAI: &AzureOpenAIConfig{ProviderConfig: ProviderConfig{Headers: map[string]string{
+			"proxy-authorization": "Basic azure",
+		}}},
+	}
+
+	openai := cfg.EffectiveHeaders("openai")
+	expected := map[string]string{
+		"Proxy-Authorization": "Basic global",
+		"X-Trace-Id":          "openai-trace",
+		"X-Team":              "infra",
+	}
+	if !reflect.DeepEqual(openai, expected) {
+		t.Errorf("expected %v, got %v", expected, openai)
+	}
+
+	if anthropic := cfg.EffectiveHeaders("anthropic"); !reflect.DeepEqual(anthropic, map[string]string{
+		"Proxy-Authorization": "Basic global",
+		"X-Trace-Id":          "global-trace",
+	}) {
+		t.Errorf("expected global headers only for anthropic, got %v", anthropic)
+	}
+
+	if azure := cfg.EffectiveHeaders("azure_openai"); azure["Proxy-Authorization"] != "Basic azure" {
+		t.Errorf("expected azure header to win, got %v", azure)
+	}
+
+	// An unconfigured provider still gets the global headers
+	if gemini := cfg.EffectiveHeaders("gemini"); len(gemini) != 2 {
+		t.Errorf("expected global headers for unconfigured provider, got %v", gemini)
+	}
+
+	// The result is a copy
+	openai["X-Team"] = "changed"
+	if cfg.OpenAI.Headers["X-Team"] != "infra" {
+		t.Error("expected EffectiveHeaders to return a new map")
+	}
+
+	if headers := (&Config{OpenAI: &ProviderConfig{}}).EffectiveHeaders("openai"); headers != nil {
+		t.Errorf("expected nil when no headers are configured, got %v", headers)
+	}
+}
+
+func TestLoadConfig_Headers(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	writeTestConfig(t, configPath, `{
+  "global_headers": {"X-Trace-Id": "abc"},
+  "openai": {"api_key": "key", "headers": {"X-Team": "infra"}}
+}`)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.GlobalHeaders["X-Trace-Id"] != "abc" {
+		t.Errorf("expected global header to load, got %v", cfg.GlobalHeaders)
+	}
+	if cfg.OpenAI.Headers["X-Team"] != "infra" {
+		t.Errorf("expected provider header to load, got %v", cfg.OpenAI.Headers)
+	}
+
+	clone := cfg.Clone()
+	clone.GlobalHeaders["X-Trace-Id"] = "changed"
+	clone.OpenAI.Headers["X-Team"] = "changed"
+	if cfg.GlobalHeaders["X-Trace-Id"] != "abc" || cfg.OpenAI.Headers["X-Team"] != "infra" {
+		t.Error("expected Clone to copy header maps")
+	}
+}
+
+func TestConfigEqual(t *testing.T) {
+	a := DefaultConfig()
+	a.OpenAI.Headers = map[string]string{"X-Team": "search"}
+	a.OpenAI.ExtraBody = map[string]interface{}{"reasoning": map[string]interface{}{"effort": "low"}}
+	b := a.Clone()
+
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Fatal("expected cloned config to be equal")
+	}
+	if !a.Equal(a) {
+		t.Error("expected config to equal itself")
+	}
+
+	b.Anthropic.Model = "claude-3-opus-20240229"
+	if a.Equal(b) {
+		t.Error("expected configs differing in one provider's model to be unequal")
+	}
+
+	b = a.Clone()
+	b.AzureOpenAI.DeploymentName = "other-deployment"
+	if a.Equal(b) {
+		t.Error("expected configs differing in Azure deployment name to be unequal")
+	}
+
+	b = a.Clone()
+	b.AzureOpenAI.APIKey = "azure-key"
+	if a.Equal(b) {
+		t.Error("expected configs differing in embedded Azure provider settings to be unequal")
+	}
+
+	b = a.Clone()
+	b.OpenAI.ExtraBody["reasoning"].(map[string]interface{})["effort"] = "high"
+	if a.Equal(b) {
+		t.Error("expected configs differing in extra_body to be unequal")
+	}
+
+	b = a.Clone()
+	b.PrivacyFilter.Level = privacy.FilterLevelStrict
+	if a.Equal(b) {
+		t.Error("expected configs differing in privacy level to be unequal")
+	}
+}
+
+func TestConfigEqual_NilAndEmpty(t *testing.T) {
+	withNil := &Config{DefaultProvider: "openai", OpenAI: &ProviderConfig{Model: "gpt-4o"}}
+	withEmpty := withNil.Clone()
+	withEmpty.Gemini = &ProviderConfig{}
+
+	if withNil.Equal(withEmpty) || withEmpty.Equal(withNil) {
+		t.Error("expected nil and empty provider blocks to differ")
+	}
+
+	withEmptyAzure := withNil.Clone()
+	withEmptyAzure.AzureOpenAI = &AzureOpenAIConfig{}
+	if withNil.Equal(withEmptyAzure) {
+		t.Error("expected nil and empty Azure blocks to differ")
+	}
+
+	withEmptyMaps := withNil.Clone()
+	withEmptyMaps.OpenAI.Headers = map[string]string{}
+	withEmptyMaps.OpenAI.ExtraBody = map[string]interface{}{}
+	withEmptyMaps.GlobalHeaders = map[string]string{}
+	if !withNil.Equal(withEmptyMaps) {
+		t.Error("expected nil and empty maps to be equal")
+	}
+
+	filterA := &Config{PrivacyFilter: privacy.DefaultFilterConfig()}
+	filterB := filterA.Clone()
+	filterB.PrivacyFilter.CustomPatterns = nil
+	if !filterA.Equal(filterB) {
+		t.Error("expected nil and empty custom pattern lists to be equal")
+	}
+
+	var nilConfig *Config
+	if !nilConfig.Equal(nil) {
+		t.Error("expected two nil configs to be equal")
+	}
+	if nilConfig.Equal(&Config{}) || (&Config{}).Equal(nil) {
+		t.Error("expected nil and empty configs to differ")
+	}
+}
+
+func TestProviderEnabled(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	writeTestConfig(t, configPath, `{
+  "default_provider": "openai",
+  "openai": {"api_key": "openai-key"},
+  "anthropic": {"api_key": "anthropic-key", "base_url": "ftp://broken", "enabled": false}
+}`)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	if cfg.Anthropic == nil || cfg.Anthropic.APIKey != "anthropic-key" {
+		t.Fatalf("expected disabled provider config to still load, got %+v", cfg.Anthropic)
+	}
+	if cfg.Anthropic.IsEnabled() {
+		t.Error("expected anthropic to be disabled")
+	}
+	if !cfg.OpenAI.IsEnabled() {
+		t.Error("expected openai to be enabled when enabled is unset")
+	}
+
+	listed := cfg.ListConfiguredProviders()
+	if contains(listed, "anthropic") {
+		t.Errorf("expected disabled provider to be excluded, got %v", listed)
+	}
+	if !contains(listed, "openai") {
+		t.Errorf("expected openai to be listed, got %v", listed)
+	}
+
+	if _, ok := cfg.ValidateAllAvailable()["anthropic"]; ok {
+		t.Error("expected ValidateAllAvailable to skip the disabled provider")
+	}
+	if err := cfg.ValidateProviderAvailable("anthropic"); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected disabled error, got %v", err)
+	}
+
+	for _, err := range cfg.validationErrors() {
+		if strings.HasPrefix(err.Field, "anthropic") {
+			t.Errorf("expected disabled provider to be skipped by validation, got %v", err)
+		}
+	}
+
+	enabled := true
+	cfg.Anthropic.Enabled = &enabled
+	if !contains(cfg.ListConfiguredProviders(), "anthropic") {
+		t.Error("expected explicitly enabled provider to be listed")
+	}
+	flagged := false
+	for _, err := range cfg.validationErrors() {
+		flagged = flagged || strings.HasPrefix(err.Field, "anthropic")
+	}
+	if !flagged {
+		t.Error("expected the enabled provider's invalid base URL to be flagged")
+	}
+
+	clone := cfg.Clone()
+	*clone.Anthropic.Enabled = false
+	if !cfg.Anthropic.IsEnabled() {
+		t.Error("expected Clone to copy the enabled flag")
+	}
+}
+
+func TestListConfiguredProviders_SkipsDefaults(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	writeTestConfig(t, configPath, `{"default_provider": "anthropic", "openai": {"api_key": "openai-key"}}`)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.Ollama == nil || cfg.Ollama.BaseURL == "" {
+		t.Fatal("expected the ollama defaults to be merged")
+	}
+	if listed := cfg.ListConfiguredProviders(); !reflect.DeepEqual(listed, []string{"openai", "anthropic"}) {
+		t.Errorf("expected only the configured and default providers, got %v", listed)
+	}
+	if _, ok := cfg.ValidateAllAvailable()["ollama"]; ok {
+		t.Error("expected ValidateAllAvailable to skip the defaulted ollama block")
+	}
+	if listed := cfg.Clone().ListConfiguredProviders(); len(listed) != 2 {
+		t.Errorf("expected Clone to keep the defaulted blocks unlisted, got %v", listed)
+	}
+
+	if err := cfg.SetProviderConfig("ollama", &ProviderConfig{Model: "qwen2.5:latest"}); err != nil {
+		t.Fatalf("SetProviderConfig returned error: %v", err)
+	}
+	if err := cfg.SetOverride("gemini.model", "gemini-2.5-pro"); err != nil {
+		t.Fatalf("SetOverride returned error: %v", err)
+	}
+	listed := cfg.ListConfiguredProviders()
+	if !contains(listed, "ollama") || !contains(listed, "gemini") || contains(listed, "deepseek") {
+		t.Errorf("expected providers set after loading to be listed, got %v", listed)
+	}
+}
+
+func TestMergeProviderConfig_Retry(t *testing.T) {
+	defaults := &ProviderConfig{Retry: &RetryConfig{MaxAttempts: 3, InitialBackoffMs: 250, MaxBackoffMs: 4000, Multiplier: 2}}
+
+	provider := &ProviderConfig{}
+	mergeProviderConfig(provider, defaults)
+	if provider.Retry == nil || *provider.Retry != *defaults.Retry {
+		t.Errorf("expected default retry settings to be used, got %+v", provider.Retry)
+	}
+
+	provider = &ProviderConfig{Retry: &RetryConfig{MaxAttempts: 5, Multiplier: 1.5}}
+	mergeProviderConfig(provider, defaults)
+	expected := RetryConfig{MaxAttempts: 5, InitialBackoffMs: 250, MaxBackoffMs: 4000, Multiplier: 1.5}
+	if *provider.Retry != expected {
+		t.Errorf("expected missing retry fields to be merged, got %+v", *provider.Retry)
+	}
+
+	provider = &ProviderConfig{Retry: &RetryConfig{MaxAttempts: 2}}
+	mergeProviderConfig(provider, &ProviderConfig{})
+	if *provider.Retry != (RetryConfig{MaxAttempts: 2}) {
+		t.Errorf("expected retry settings to be kept without defaults, got %+v", *provider.Retry)
+	}
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	override := filepath.Join(dir, "override.json")
+	writeTestConfig(t, base, `{"openai": {"retry": {"max_attempts": 4, "initial_backoff_ms": 100, "max_backoff_ms": 2000, "multiplier": 2}}}`)
+	writeTestConfig(t, override, `{"openai": {"retry": {"max_attempts": 6}}}`)
+
+	cfg, err := LoadConfigLayered(base, override)
+	if err != nil {
+		t.Fatalf("LoadConfigLayered returned error: %v", err)
+	}
+	expected = RetryConfig{MaxAttempts: 6, InitialBackoffMs: 100, MaxBackoffMs: 2000, Multiplier: 2}
+	if cfg.OpenAI.Retry == nil || *cfg.OpenAI.Retry != expected {
+		t.Errorf("expected layered retry settings to merge, got %+v", cfg.OpenAI.Retry)
+	}
+
+	clone := cfg.Clone()
+	clone.OpenAI.Retry.MaxAttempts = 1
+	if cfg.OpenAI.Retry.MaxAttempts != 6 {
+		t.Error("expected Clone to copy retry settings")
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	before := &Config{
+		DefaultProvider: "openai",
+		OpenAI: &ProviderConfig{
+			APIKey:  "sk-old-secret-key",
+			Model:   "gpt-4o-mini",
+			Headers: map[string]string{"X-Team": "search"},
+		},
+	}
+	after := &Config{
+		DefaultProvider: "openai",
+		OpenAI: &ProviderConfig{
+			APIKey:  "sk-new-secret-key",
+			Model:   "gpt-4o",
+			Headers: map[string]string{"X-Team": "platform"},
+		},
+		Anthropic: &ProviderConfig{
+			APIKey: "sk-ant-secret-key",
+			Model:  "claude-3-5-haiku-latest",
+		},
+	}
+
+	diffs := before.Diff(after)
+	expected := []FieldDiff{
+		{Path: "anthropic", New: map[string]interface{}{"api_key": "[REDACTED]", "model": "claude-3-5-haiku-latest"}},
+		{Path: "openai.api_key", Old: "[REDACTED]", New: "[REDACTED]"},
+		{Path: "openai.headers.X-Team", Old: "[REDACTED]", New: "[REDACTED]"},
+		{Path: "openai.model", Old: "gpt-4o-mini", New: "gpt-4o"},
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expected diffs %#v, got %#v", expected, diffs)
+	}
+
+	data, err := json.Marshal(diffs)
+	if err != nil {
+		t.Fatalf("failed to marshal diffs: %v", err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Errorf("expected API keys to be redacted, got %s", data)
+	}
+
+	if diffs := after.Diff(after); len(diffs) != 0 {
+		t.Errorf("expected no diffs for identical configs, got %v", diffs)
+	}
+	if diffs := (&Config{GlobalHeaders: map[string]string{}}).Diff(&Config{}); len(diffs) != 0 {
+		t.Errorf("expected empty and nil maps to be the same, got %v", diffs)
+	}
+
+	removed := after.Diff(before)
+	if len(removed) == 0 || removed[0].Path != "anthropic" || removed[0].New != nil {
+		t.Errorf("expected removed provider to be reported, got %v", removed)
+	}
+}
+
+func TestConfigDiff_PrivacyFilterSecrets(t *testing.T) {
+	before := &Config{PrivacyFilter: &privacy.FilterConfig{
+		LiteralDenylist: []string{"hunter2hunter2"},
+		HashSalt:        "old-salt-value",
+	}}
+	after := &Config{PrivacyFilter: &privacy.FilterConfig{
+		LiteralDenylist: []string{"hunter2hunter2", "correcthorsebattery"},
+		HashSalt:        "new-salt-value",
+	}}
+
+	diffs := before.Diff(after)
+	expected := []FieldDiff{
+		{Path: "privacy_filter.hash_salt", Old: "[REDACTED]", New: "[REDACTED]"},
+		{Path: "privacy_filter.literal_denylist", Old: []interface{}{"[REDACTED]"}, New: []interface{}{"[REDACTED]", "[REDACTED]"}},
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expected diffs %#v, got %#v", expected, diffs)
+	}
+
+	data, err := json.Marshal((&Config{}).Diff(after))
+	if err != nil {
+		t.Fatalf("failed to marshal diffs: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "correcthorse", "salt-value"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("expected %q to be redacted from an added privacy_filter block, got %s", secret, data)
+		}
+	}
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	exists, readable, err := CheckConfigFile(filepath.Join(dir, "missing.json"))
+	if exists || readable || err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing file: expected not found, got exists=%v readable=%v err=%v", exists, readable, err)
+	}
+
+	exists, readable, err = CheckConfigFile(dir)
+	if !exists || readable || err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("directory: expected directory error, got exists=%v readable=%v err=%v", exists, readable, err)
+	}
+	if _, err := LoadConfig(dir); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("expected LoadConfig to report the directory, got %v", err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"default_provider": "openai"}`), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if exists, readable, err := CheckConfigFile(path); !exists || !readable || err != nil {
+		t.Errorf("readable file: expected no error, got exists=%v readable=%v err=%v", exists, readable, err)
+	}
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("file permissions cannot make a file unreadable here")
+	}
+	if err := os.Chmod(path, 0000); err != nil {
+		t.Fatalf("failed to chmod config: %v", err)
+	}
+	exists, readable, err = CheckConfigFile(path)
+	if !exists || readable || err == nil || !strings.Contains(err.Error(), "not readable") {
+		t.Errorf("unreadable file: expected permission error, got exists=%v readable=%v err=%v", exists, readable, err)
+	}
+}
+
+func TestProviderStream(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"openai": {"model": "gpt-4o"}, "openai_compatible": {"stream": false}}`), &cfg); err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	if cfg.OpenAI.Stream != nil || !cfg.OpenAI.StreamEnabled() {
+		t.Errorf("expected streaming to default to enabled, got %v", cfg.OpenAI.Stream)
+	}
+	if cfg.OpenAICompatible.Stream == nil || cfg.OpenAICompatible.StreamEnabled() {
+		t.Errorf("expected stream: false to disable streaming, got %v", cfg.OpenAICompatible.Stream)
+	}
+
+	data, err := json.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	if strings.Count(string(data), `"stream"`) != 1 || !strings.Contains(string(data), `"stream":false`) {
+		t.Errorf("expected only the explicit stream setting to be written, got %s", data)
+	}
+	var roundTripped Config
+	if err := json.Unmarshal(data, &roundTripped); err != nil {
+		t.Fatalf("failed to unmarshal round-tripped config: %v", err)
+	}
+	if !roundTripped.Equal(&cfg) {
+		t.Errorf("expected round-tripped config to be equal, got %s", data)
+	}
+
+	disabled := false
+	provider := &ProviderConfig{}
+	mergeProviderConfig(provider, &ProviderConfig{Stream: &disabled})
+	if provider.StreamEnabled() {
+		t.Error("expected merge to take stream from the default provider")
+	}
+	enabled := true
+	provider = &ProviderConfig{Stream: &enabled}
+	mergeProviderConfig(provider, &ProviderConfig{Stream: &disabled})
+	if !provider.StreamEnabled() {
+		t.Error("expected an explicit stream setting to win over the default")
+	}
+
+	if clone := cloneProviderConfig(provider); clone.Stream == provider.Stream {
+		t.Error("expected clone to copy the stream flag")
+	}
+}
+
+func TestConfigSourcePath(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "10-base.json")
+	override := filepath.Join(dir, "20-override.toml")
+	if err := os.WriteFile(base, []byte(`{"default_provider": "openai"}`), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(override, []byte("default_provider = \"anthropic\"\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if got := DefaultConfig().SourcePath(); got != "" {
+		t.Errorf("expected no source path for DefaultConfig, got %q", got)
+	}
+
+	loaders := map[string]func() (*Config, error){
+		"LoadConfig":    func() (*Config, error) { return LoadConfig(base) },
+		"LoadConfigRaw": func() (*Config, error) { return LoadConfigRaw(base) },
+		"LoadConfigFromEnv": func() (*Config, error) {
+			t.Setenv("SMART_SUGGESTION_PROVIDER_FILE", base)
+			return LoadConfigFromEnv()
+		},
+	}
+	for name, load := range loaders {
+		cfg, err := load()
+		if err != nil {
+			t.Fatalf("%s returned error: %v", name, err)
+		}
+		if cfg.SourcePath() != base || !reflect.DeepEqual(cfg.SourcePaths(), []string{base}) {
+			t.Errorf("%s: expected source path %s, got %q %v", name, base, cfg.SourcePath(), cfg.SourcePaths())
+		}
+		if clone := cfg.Clone(); clone.SourcePath() != base {
+			t.Errorf("%s: expected Clone to keep the source path, got %q", name, clone.SourcePath())
+		}
+	}
+
+	layered, err := LoadConfigLayered(base, filepath.Join(dir, "missing.json"), override)
+	if err != nil {
+		t.Fatalf("LoadConfigLayered returned error: %v", err)
+	}
+	if layered.SourcePath() != override || !reflect.DeepEqual(layered.SourcePaths(), []string{base, override}) {
+		t.Errorf("LoadConfigLayered: expected %v, got %q %v", []string{base, override}, layered.SourcePath(), layered.SourcePaths())
+	}
+
+	fromDir, err := LoadConfigDir(dir)
+	if err != nil {
+		t.Fatalf("LoadConfigDir returned error: %v", err)
+	}
+	if !reflect.DeepEqual(fromDir.SourcePaths(), []string{base, override}) {
+		t.Errorf("LoadConfigDir: expected %v, got %v", []string{base, override}, fromDir.SourcePaths())
+	}
+}
+
+func TestLoadConfigStrictSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	misspelled := filepath.Join(dir, "misspelled.json")
+	if err := os.WriteFile(misspelled, []byte(`{"default_provider": "openai", "openai": {"modle": "gpt-4o"}}`), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadConfigStrictSchema(misspelled); err == nil || !strings.Contains(err.Error(), `"modle"`) {
+		t.Errorf("expected an error naming the unknown field, got %v", err)
+	}
+	if _, err := LoadConfig(misspelled); err != nil {
+		t.Errorf("expected LoadConfig to ignore unknown fields, got %v", err)
+	}
+
+	tomlPath := filepath.Join(dir, "misspelled.toml")
+	if err := os.WriteFile(tomlPath, []byte("default_provider = \"openai\"\ndefualt_provider = \"anthropic\"\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadConfigStrictSchema(tomlPath); err == nil || !strings.Contains(err.Error(), `"defualt_provider"`) {
+		t.Errorf("expected an error naming the unknown TOML field, got %v", err)
+	}
+
+	clean := filepath.Join(dir, "clean.json")
+	if err := os.WriteFile(clean, []byte(`{"default_provider": "openai", "openai": {"model": "gpt-4o"}}`), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := LoadConfigStrictSchema(clean)
+	if err != nil {
+		t.Fatalf("expected clean config to load, got %v", err)
+	}
+	if cfg.OpenAI == nil || cfg.OpenAI.Model != "gpt-4o" || cfg.SourcePath() != clean {
+		t.Errorf("expected clean config to be loaded from %s, got %+v", clean, cfg)
+	}
+
+	trailing := filepath.Join(dir, "trailing.json")
+	if err := os.WriteFile(trailing, []byte(`{"default_provider": "openai"} {}`), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadConfigStrictSchema(trailing); err == nil {
+		t.Error("expected trailing data to be rejected")
+	}
+}
+
+func TestProviderSystemPrompt(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"anthropic": {"model": "claude-3-5-sonnet-20241022", "system_prompt": "Suggest zsh commands only."}}`), &cfg); err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	if cfg.SystemPrompt("anthropic") != "Suggest zsh commands only." {
+		t.Errorf("expected system prompt to be read, got %q", cfg.SystemPrompt("anthropic"))
+	}
+	if cfg.SystemPrompt("openai") != "" {
+		t.Errorf("expected no system prompt for an unconfigured provider, got %q", cfg.SystemPrompt("openai"))
+	}
+
+	data, err := json.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	if strings.Count(string(data), `"system_prompt"`) != 1 {
+		t.Errorf("expected only the set system prompt to be written, got %s", data)
+	}
+	var roundTripped Config
+	if err := json.Unmarshal(data, &roundTripped); err != nil {
+		t.Fatalf("failed to unmarshal round-tripped config: %v", err)
+	}
+	if !roundTripped.Equal(&cfg) {
+		t.Errorf("expected round-tripped config to be equal, got %s", data)
+	}
+
+	provider := &ProviderConfig{SystemPrompt: "from config"}
+	mergeProviderConfig(provider, &ProviderConfig{SystemPrompt: "default"})
+	if provider.SystemPrompt != "from config" {
+		t.Errorf("expected the configured system prompt to win, got %q", provider.SystemPrompt)
+	}
+	provider = &ProviderConfig{}
+	mergeProviderConfig(provider, &ProviderConfig{SystemPrompt: "default"})
+	if provider.SystemPrompt != "default" {
+		t.Errorf("expected an empty system prompt to take the default, got %q", provider.SystemPrompt)
+	}
+
+	if errors := validateProviderConfig("anthropic", &ProviderConfig{SystemPrompt: strings.Repeat("x", MaxSystemPromptLength)}); errors.hasField("anthropic.system_prompt") {
+		t.Errorf("expected a prompt at the limit to be valid, got %v", errors)
+	}
+	if errors := validateProviderConfig("anthropic", &ProviderConfig{SystemPrompt: strings.Repeat("x", MaxSystemPromptLength+1)}); !errors.hasField("anthropic.system_prompt") {
+		t.Errorf("expected an overlong prompt to be rejected, got %v", errors)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	write(`{"default_provider": "openai", "openai": {"model": "gpt-4o"}}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.SetOverride("default_provider", "anthropic"); err != nil {
+		t.Fatalf("failed to set override: %v", err)
+	}
+	if cfg.DefaultProvider != "anthropic" {
+		t.Errorf("expected override to apply immediately, got %q", cfg.DefaultProvider)
+	}
+
+	write(`{"default_provider": "openai", "openai": {"model": "gpt-4o-mini"}}`)
+	if err := cfg.Reload(""); err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	if cfg.OpenAI.Model != "gpt-4o-mini" {
+		t.Errorf("expected the edited model to be reloaded, got %q", cfg.OpenAI.Model)
+	}
+	if cfg.DefaultProvider != "anthropic" {
+		t.Errorf("expected the override to survive the reload, got %q", cfg.DefaultProvider)
+	}
+	if cfg.Anthropic == nil || cfg.Anthropic.BaseURL == "" {
+		t.Error("expected defaults to be merged on reload")
+	}
+	if cfg.SourcePath() != path || !reflect.DeepEqual(cfg.Overrides(), []string{"default_provider"}) {
+		t.Errorf("expected source path and overrides to be kept, got %q and %v", cfg.SourcePath(), cfg.Overrides())
+	}
+
+	snapshot := cfg.Snapshot()
+	cfg.ClearOverrides()
+	if err := cfg.Reload(path); err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	if cfg.DefaultProvider != "openai" || len(cfg.Overrides()) != 0 {
+		t.Errorf("expected cleared overrides not to be re-applied, got %q", cfg.DefaultProvider)
+	}
+	if snapshot.DefaultProvider != "anthropic" {
+		t.Errorf("expected the snapshot not to change on reload, got %q", snapshot.DefaultProvider)
+	}
+
+	if err := cfg.SetOverride("openai.modle", "gpt-4o"); err == nil || !strings.Contains(err.Error(), `"modle"`) {
+		t.Errorf("expected an unknown override path to be rejected, got %v", err)
+	}
+	if err := cfg.SetOverride("openai.model", 42); err == nil {
+		t.Error("expected an override of the wrong type to be rejected")
+	}
+	if len(cfg.Overrides()) != 0 || cfg.OpenAI.Model != "gpt-4o-mini" {
+		t.Errorf("expected rejected overrides to leave the config unchanged, got %v and %q", cfg.Overrides(), cfg.OpenAI.Model)
+	}
+
+	write(`{"default_provider": `)
+	if err := cfg.Reload(path); err == nil || cfg.OpenAI.Model != "gpt-4o-mini" {
+		t.Errorf("expected a broken file to fail the reload and keep the config, got %v", err)
+	}
+}
+
+func TestConfigReload_Layered(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "config.json")
+	projectPath := filepath.Join(dir, ".smart-suggestion.json")
+	writeTestConfig(t, globalPath, `{"default_provider": "anthropic", "anthropic": {"model": "claude-3-5-haiku-latest"}}`)
+	writeTestConfig(t, projectPath, `{"openai": {"model": "gpt-4o"}}`)
+
+	cfg, err := LoadConfigLayered(globalPath, projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigLayered returned error: %v", err)
+	}
+
+	writeTestConfig(t, projectPath, `{"openai": {"model": "gpt-4o-mini"}}`)
+	if err := cfg.Reload(""); err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	if cfg.OpenAI.Model != "gpt-4o-mini" {
+		t.Errorf("expected the edited project layer to be reloaded, got %q", cfg.OpenAI.Model)
+	}
+	if cfg.DefaultProvider != "anthropic" || cfg.Anthropic.Model != "claude-3-5-haiku-latest" {
+		t.Errorf("expected the global layer to be kept on reload, got %q and %q", cfg.DefaultProvider, cfg.Anthropic.Model)
+	}
+	if !reflect.DeepEqual(cfg.SourcePaths(), []string{globalPath, projectPath}) {
+		t.Errorf("expected both source paths to be kept, got %v", cfg.SourcePaths())
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ConnectivityErrorKind categorizes why a provider endpoint could not be reached
+type ConnectivityErrorKind string
+
+const (
+	// ConnectivityDNS means the base URL host could not be resolved
+	ConnectivityDNS ConnectivityErrorKind = "dns"
+	// ConnectivityTLS means the TLS handshake or certificate verification failed
+	ConnectivityTLS ConnectivityErrorKind = "tls"
+	// ConnectivityTimeout means the endpoint did not respond within the timeout
+	ConnectivityTimeout ConnectivityErrorKind = "timeout"
+	// ConnectivityHTTPStatus means the endpoint responded with a failing status code
+	ConnectivityHTTPStatus ConnectivityErrorKind = "http_status"
+	// ConnectivityNetwork covers any other transport failure, such as a refused connection
+	ConnectivityNetwork ConnectivityErrorKind = "network"
+)
+
+// ConnectivityError describes a failed connectivity check for a provider
+type ConnectivityError struct {
+	Provider   string
+	URL        string
+	Kind       ConnectivityErrorKind
+	StatusCode int
+	Err        error
+}
+
+func (e *ConnectivityError) Error() string {
+	if e.Kind == ConnectivityHTTPStatus {
+		return fmt.Sprintf("%s: %s returned HTTP status %d", e.Provider, e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s check failed for %s: %v", e.Provider, e.Kind, e.URL, e.Err)
+}
+
+func (e *ConnectivityError) Unwrap() error {
+	return e.Err
+}
+
+// CheckConnectivity sends a lightweight GET to the provider's base URL with the configured
+// auth headers and reports whether it is reachable. It never requests a completion and is
+// not part of Validate. Any response counts as reachable except authentication failures
+// (401, 403, 407) and server errors, which are reported as ConnectivityHTTPStatus.
+func (c *Config) CheckConnectivity(provider string, timeout time.Duration) error {
+	baseURL, err := c.connectivityURL(provider)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	apiKey, _ := c.GetAPIKey(provider)
+	pc, _ := c.GetProviderConfig(provider)
+	setAuthHeaders(req, provider, apiKey, pc)
+	setHeaders(req, c.EffectiveHeaders(provider))
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &ConnectivityError{
+			Provider: provider,
+			URL:      baseURL,
+			Kind:     classifyConnectivityError(err),
+			Err:      err,
+		}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusForbidden,
+		resp.StatusCode == http.StatusProxyAuthRequired,
+		resp.StatusCode >= 500:
+		return &ConnectivityError{
+			Provider:   provider,
+			URL:        baseURL,
+			Kind:       ConnectivityHTTPStatus,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	return nil
+}
+
+// connectivityURL returns the base URL to probe for the provider
+func (c *Config) connectivityURL(provider string) (string, error) {
+	if provider == "azure_openai" {
+		if c.AzureOpenAI == nil {
+			return "", fmt.Errorf("Azure OpenAI configuration not found")
+		}
+		if c.AzureOpenAI.BaseURL != "" {
+			return c.AzureOpenAI.BaseURL, nil
+		}
+		if c.AzureOpenAI.ResourceName != "" {
+			return fmt.Sprintf("https://%s.openai.azure.com", c.AzureOpenAI.ResourceName), nil
+		}
+		return "", fmt.Errorf("azure_openai base_url or resource_name is not configured")
+	}
+
+	pc, err := c.GetProviderConfig(provider)
+	if err != nil {
+		return "", err
+	}
+	if pc.BaseURL == "" {
+		return "", fmt.Errorf("%s base_url is not configured", provider)
+	}
+	return pc.BaseURL, nil
+}
+
+// defaultAnthropicVersion is the anthropic-version header sent when the provider config
+// does not set api_version
+const defaultAnthropicVersion = "2023-06-01"
+
+// setAuthHeaders sets the authentication headers the provider expects. pc, which may be
+// nil, supplies the anthropic-version header from its APIVersion.
+func setAuthHeaders(req *http.Request, provider, apiKey string, pc *ProviderConfig) {
+	if apiKey == "" {
+		return
+	}
+
+	switch provider {
+	case "anthropic":
+		version := defaultAnthropicVersion
+		if pc != nil && pc.APIVersion != "" {
+			version = pc.APIVersion
+		}
+		req.Header.Set("x-api-key", apiKey)
+		req.Header.Set("anthropic-version", version)
+	case "azure_openai":
+		req.Header.Set("api-key", apiKey)
+	case "gemini":
+		req.Header.Set("x-goog-api-key", apiKey)
+	default:
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+}
+
+// setHeaders sets each of headers on req, replacing any value already set
+func setHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// classifyConnectivityError maps a transport error to a ConnectivityErrorKind
+func classifyConnectivityError(err error) ConnectivityErrorKind {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ConnectivityDNS
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return ConnectivityTLS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ConnectivityTimeout
+	}
+
+	return ConnectivityNetwork
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckConnectivity(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer test-key" {
+				t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+			}
+			// API roots commonly answer 404; the host is still reachable
+			http.NotFound(w, r)
+		}))
+		defer server.Close()
+
+		cfg := DefaultConfig()
+		cfg.OpenAI.BaseURL = server.URL
+		cfg.OpenAI.APIKey = "test-key"
+
+		if err := cfg.CheckConnectivity("openai", time.Second); err != nil {
+			t.Errorf("expected endpoint to be reachable, got: %v", err)
+		}
+	})
+
+	t.Run("anthropic version", func(t *testing.T) {
+		var version string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			version = r.Header.Get("anthropic-version")
+		}))
+		defer server.Close()
+
+		cfg := DefaultConfig()
+		cfg.Anthropic.BaseURL = server.URL
+		cfg.Anthropic.APIKey = "test-key"
+		cfg.Anthropic.APIVersion = "2024-01-01"
+
+		if err := cfg.CheckConnectivity("anthropic", time.Second); err != nil {
+			t.Fatalf("expected endpoint to be reachable, got: %v", err)
+		}
+		if version != "2024-01-01" {
+			t.Errorf("expected the configured anthropic-version, got %q", version)
+		}
+
+		cfg.Anthropic.APIVersion = ""
+		if err := cfg.CheckConnectivity("anthropic", time.Second); err != nil {
+			t.Fatalf("expected endpoint to be reachable, got: %v", err)
+		}
+		if version != defaultAnthropicVersion {
+			t.Errorf("expected the default anthropic-version, got %q", version)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		cfg := DefaultConfig()
+		cfg.OpenAI.BaseURL = server.URL
+
+		err := cfg.CheckConnectivity("openai", time.Second)
+		var connErr *ConnectivityError
+		if !errors.As(err, &connErr) {
+			t.Fatalf("expected a ConnectivityError, got: %v", err)
+		}
+		if connErr.Kind != ConnectivityHTTPStatus || connErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected HTTP 401 error, got kind=%s status=%d", connErr.Kind, connErr.StatusCode)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		cfg := DefaultConfig()
+		cfg.OpenAI.BaseURL = server.URL
+
+		err := cfg.CheckConnectivity("openai", 50*time.Millisecond)
+		var connErr *ConnectivityError
+		if !errors.As(err, &connErr) || connErr.Kind != ConnectivityTimeout {
+			t.Errorf("expected a timeout error, got: %v", err)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		if err := DefaultConfig().CheckConnectivity("unknown", time.Second); err == nil {
+			t.Error("expected error for unknown provider")
+		}
+	})
+}
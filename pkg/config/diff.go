@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+)
+
+// redactedDiffValue replaces secret values in a FieldDiff
+const redactedDiffValue = "[REDACTED]"
+
+// FieldDiff describes one setting that differs between two configurations. Path is the
+// dotted JSON path of the setting, such as openai.model. Old is nil for an added setting
+// and New is nil for a removed one; a whole provider block added or removed is reported
+// as a single FieldDiff holding the block's settings.
+type FieldDiff struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Diff lists the settings that differ from c to other, sorted by path. Values are
+// compared in their encoded form, so nil and empty maps and slices are the same, as in
+// Equal. API keys and header values are redacted, since the diff is meant to be shown.
+func (c *Config) Diff(other *Config) []FieldDiff {
+	before, err := configFields(c)
+	if err != nil {
+		return nil
+	}
+	after, err := configFields(other)
+	if err != nil {
+		return nil
+	}
+
+	var diffs []FieldDiff
+	diffFields("", before, after, false, &diffs)
+	return diffs
+}
+
+// configFields returns the JSON encoding of cfg decoded into nested maps. A nil config
+// has no fields.
+func configFields(cfg *Config) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if cfg == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// diffFields appends the differences between two decoded objects at prefix to diffs.
+// Objects present on both sides are compared key by key; anything else is compared
+// as a whole. headers is set when the objects are header blocks, whose values are
+// all redacted.
+func diffFields(prefix string, before, after map[string]interface{}, headers bool, diffs *[]FieldDiff) {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		oldValue := before[key]
+		newValue := after[key]
+		oldObject, oldIsObject := oldValue.(map[string]interface{})
+		newObject, newIsObject := newValue.(map[string]interface{})
+		switch {
+		case oldIsObject && newIsObject:
+			diffFields(path, oldObject, newObject, isHeadersKey(key), diffs)
+		case !reflect.DeepEqual(oldValue, newValue):
+			diff := FieldDiff{Path: path, Old: redactDiffValue(key, oldValue), New: redactDiffValue(key, newValue)}
+			if headers {
+				diff.Old, diff.New = redactHeaderValue(oldValue), redactHeaderValue(newValue)
+			}
+			*diffs = append(*diffs, diff)
+		}
+	}
+}
+
+// redactDiffValue returns value with secrets replaced by redactedDiffValue. key is the
+// name value is stored under: api_key and hash_salt values, literal_denylist entries and
+// every value of a headers block are redacted, and objects are redacted recursively.
+func redactDiffValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for name, nested := range v {
+			if isHeadersKey(key) {
+				redacted[name] = redactHeaderValue(nested)
+			} else {
+				redacted[name] = redactDiffValue(name, nested)
+			}
+		}
+		return redacted
+	}
+
+	switch key {
+	case "api_key", "hash_salt":
+		return redactedDiffValue
+	case "literal_denylist":
+		if entries, ok := value.([]interface{}); ok {
+			redacted := make([]interface{}, len(entries))
+			for i := range entries {
+				redacted[i] = redactedDiffValue
+			}
+			return redacted
+		}
+		return redactedDiffValue
+	}
+	return value
+}
+
+// redactHeaderValue returns redactedDiffValue for a set header value and nil otherwise
+func redactHeaderValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redactedDiffValue
+}
+
+// isHeadersKey reports whether key names a block of HTTP headers
+func isHeadersKey(key string) bool {
+	return key == "headers" || key == "global_headers"
+}
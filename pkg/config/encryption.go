@@ -0,0 +1,226 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// encryptionVersion is the current version of the encrypted config envelope
+	encryptionVersion = 1
+
+	// encryptedValuePrefix marks an API key value encrypted with the current envelope version
+	encryptedValuePrefix = "enc:v1:"
+
+	// encryptionVerifier is encrypted alongside the keys so a wrong passphrase is
+	// detected even when no API keys are configured
+	encryptionVerifier = "smart-suggestion"
+
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// ErrInvalidPassphrase is returned when encrypted API keys cannot be decrypted
+var ErrInvalidPassphrase = errors.New("failed to decrypt config: wrong passphrase or corrupted data")
+
+// encryptionEnvelope describes how the API keys in a config file were encrypted
+type encryptionEnvelope struct {
+	Version  int    `json:"version"`
+	KDF      string `json:"kdf"`
+	Salt     string `json:"salt"`
+	N        int    `json:"n"`
+	R        int    `json:"r"`
+	P        int    `json:"p"`
+	Verifier string `json:"verifier"`
+}
+
+// encryptedConfigFile is the on-disk layout of an encrypted config file.
+// Non-secret fields are stored as plain JSON next to the envelope.
+type encryptedConfigFile struct {
+	Encryption *encryptionEnvelope `json:"encryption"`
+	*Config
+}
+
+// SaveConfigEncrypted saves the configuration with every API key encrypted using
+// AES-GCM and a scrypt-derived key from passphrase
+func (c *Config) SaveConfigEncrypted(configPath, passphrase string) error {
+	if configPath == "" {
+		return fmt.Errorf("config file path is required")
+	}
+	if passphrase == "" {
+		return fmt.Errorf("passphrase is required")
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	envelope := &encryptionEnvelope{
+		Version: encryptionVersion,
+		KDF:     "scrypt",
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+	}
+
+	gcm, err := newEnvelopeCipher(envelope, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if envelope.Verifier, err = encryptValue(gcm, encryptionVerifier); err != nil {
+		return err
+	}
+
+	encrypted := c.Clone()
+	for provider, pc := range encrypted.providerConfigs() {
+		if pc.APIKey == "" {
+			continue
+		}
+		if pc.APIKey, err = encryptValue(gcm, pc.APIKey); err != nil {
+			return fmt.Errorf("failed to encrypt %s API key: %w", provider, err)
+		}
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(encryptedConfigFile{Encryption: envelope, Config: encrypted}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Write with restricted permissions
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadConfigEncrypted loads a config file written by SaveConfigEncrypted and decrypts its API keys.
+// A wrong passphrase returns ErrInvalidPassphrase.
+func LoadConfigEncrypted(configPath, passphrase string) (*Config, error) {
+	if configPath == "" {
+		return nil, fmt.Errorf("config file path is required")
+	}
+
+	// Check if config file exists
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file not found: %s", configPath)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	file := encryptedConfigFile{Config: &Config{}}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if file.Encryption == nil {
+		return nil, fmt.Errorf("config file is not encrypted: %s", configPath)
+	}
+	if file.Encryption.Version != encryptionVersion {
+		return nil, fmt.Errorf("unsupported encryption version: %d", file.Encryption.Version)
+	}
+
+	gcm, err := newEnvelopeCipher(file.Encryption, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if verifier, err := decryptValue(gcm, file.Encryption.Verifier); err != nil || verifier != encryptionVerifier {
+		return nil, ErrInvalidPassphrase
+	}
+
+	config := file.Config
+	if err := config.Migrate(); err != nil {
+		return nil, err
+	}
+	for provider, pc := range config.providerConfigs() {
+		if !strings.HasPrefix(pc.APIKey, encryptedValuePrefix) {
+			continue
+		}
+		if pc.APIKey, err = decryptValue(gcm, pc.APIKey); err != nil {
+			return nil, fmt.Errorf("%s API key: %w", provider, err)
+		}
+	}
+
+	// Merge with defaults for missing values
+	defaultConfig := DefaultConfig()
+	mergeConfigs(config, defaultConfig)
+	config.NormalizeBaseURLs()
+
+	return config, nil
+}
+
+// newEnvelopeCipher derives the key described by envelope from passphrase and returns an AES-GCM cipher
+func newEnvelopeCipher(envelope *encryptionEnvelope, passphrase string) (cipher.AEAD, error) {
+	if envelope.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", envelope.KDF)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(envelope.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption salt: %w", err)
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, envelope.N, envelope.R, envelope.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptValue encrypts plaintext and returns it as a prefixed base64 string
+func encryptValue(gcm cipher.AEAD, plaintext string) (string, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue decrypts a value produced by encryptValue
+func decryptValue(gcm cipher.AEAD, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedValuePrefix))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrInvalidPassphrase
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrInvalidPassphrase
+	}
+
+	return string(plaintext), nil
+}
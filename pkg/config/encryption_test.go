@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadConfigEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := DefaultConfig()
+	cfg.OpenAI.APIKey = "sk-openai-secret"
+	cfg.AzureOpenAI.APIKey = "azure-secret"
+	cfg.AzureOpenAI.DeploymentName = "my-deployment"
+
+	if err := cfg.SaveConfigEncrypted(path, "correct horse"); err != nil {
+		t.Fatalf("SaveConfigEncrypted returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read encrypted config: %v", err)
+	}
+	if strings.Contains(string(data), "sk-openai-secret") || strings.Contains(string(data), "azure-secret") {
+		t.Error("expected API keys to be encrypted on disk")
+	}
+	if !strings.Contains(string(data), "gpt-4o-mini") || !strings.Contains(string(data), "my-deployment") {
+		t.Error("expected non-secret fields to remain readable")
+	}
+
+	loaded, err := LoadConfigEncrypted(path, "correct horse")
+	if err != nil {
+		t.Fatalf("LoadConfigEncrypted returned error: %v", err)
+	}
+	if loaded.OpenAI.APIKey != "sk-openai-secret" {
+		t.Errorf("expected OpenAI API key to round-trip, got %q", loaded.OpenAI.APIKey)
+	}
+	if loaded.AzureOpenAI.APIKey != "azure-secret" {
+		t.Errorf("expected Azure API key to round-trip, got %q", loaded.AzureOpenAI.APIKey)
+	}
+
+	// The in-memory config must not be modified by saving
+	if cfg.OpenAI.APIKey != "sk-openai-secret" {
+		t.Errorf("expected original config to be unchanged, got %q", cfg.OpenAI.APIKey)
+	}
+}
+
+func TestLoadConfigEncrypted_WrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := DefaultConfig()
+	cfg.Anthropic.APIKey = "sk-ant-secret"
+	if err := cfg.SaveConfigEncrypted(path, "correct horse"); err != nil {
+		t.Fatalf("SaveConfigEncrypted returned error: %v", err)
+	}
+
+	_, err := LoadConfigEncrypted(path, "wrong horse")
+	if !errors.Is(err, ErrInvalidPassphrase) {
+		t.Errorf("expected ErrInvalidPassphrase, got: %v", err)
+	}
+}
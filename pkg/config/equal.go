@@ -0,0 +1,121 @@
+package config
+
+import (
+	"maps"
+	"reflect"
+
+	"github.com/yetone/smart-suggestion/pkg/privacy"
+)
+
+// Equal reports whether c and other describe the same configuration. Provider blocks
+// are compared field by field, and a nil block differs from an empty one because only
+// the latter is written to the config file. Maps and slices that are nil or empty are
+// treated as equal since they encode the same way.
+func (c *Config) Equal(other *Config) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+
+	if c.Version != other.Version ||
+		c.DefaultProvider != other.DefaultProvider ||
+		c.BaseURLRequireScheme != other.BaseURLRequireScheme ||
+		c.RequireHTTPS != other.RequireHTTPS ||
+		!maps.Equal(c.GlobalHeaders, other.GlobalHeaders) {
+		return false
+	}
+
+	if !providerConfigEqual(c.OpenAI, other.OpenAI) ||
+		!providerConfigEqual(c.OpenAICompatible, other.OpenAICompatible) ||
+		!azureOpenAIConfigEqual(c.AzureOpenAI, other.AzureOpenAI) ||
+		!providerConfigEqual(c.Anthropic, other.Anthropic) ||
+		!providerConfigEqual(c.Gemini, other.Gemini) ||
+		!providerConfigEqual(c.DeepSeek, other.DeepSeek) ||
+		!providerConfigEqual(c.Mistral, other.Mistral) ||
+		!providerConfigEqual(c.OpenRouter, other.OpenRouter) ||
+		!providerConfigEqual(c.Ollama, other.Ollama) ||
+		!maps.EqualFunc(c.CustomProviders, other.CustomProviders, providerConfigEqual) {
+		return false
+	}
+
+	return privacyFilterEqual(c.PrivacyFilter, other.PrivacyFilter)
+}
+
+// providerConfigEqual reports whether two provider blocks are equal
+func providerConfigEqual(a, b *ProviderConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if len(a.ExtraBody) != len(b.ExtraBody) || (len(a.ExtraBody) > 0 && !reflect.DeepEqual(a.ExtraBody, b.ExtraBody)) {
+		return false
+	}
+
+	return a.APIKey == b.APIKey &&
+		a.APIKeyEnv == b.APIKeyEnv &&
+		a.BaseURL == b.BaseURL &&
+		a.Model == b.Model &&
+		a.APIVersion == b.APIVersion &&
+		a.Organization == b.Organization &&
+		a.Project == b.Project &&
+		a.EmbeddingBaseURL == b.EmbeddingBaseURL &&
+		a.SystemPrompt == b.SystemPrompt &&
+		maps.Equal(a.Headers, b.Headers) &&
+		boolPointerEqual(a.Enabled, b.Enabled) &&
+		boolPointerEqual(a.Stream, b.Stream) &&
+		retryConfigEqual(a.Retry, b.Retry)
+}
+
+// retryConfigEqual reports whether two retry settings are both unset or equal
+func retryConfigEqual(a, b *RetryConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// boolPointerEqual reports whether two optional flags are both unset or set to the same value
+func boolPointerEqual(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// azureOpenAIConfigEqual reports whether two Azure OpenAI blocks are equal, including
+// their embedded provider settings
+func azureOpenAIConfigEqual(a, b *AzureOpenAIConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.ResourceName == b.ResourceName &&
+		a.DeploymentName == b.DeploymentName &&
+		providerConfigEqual(&a.ProviderConfig, &b.ProviderConfig)
+}
+
+// privacyFilterEqual reports whether two privacy filter configurations are equal
+func privacyFilterEqual(a, b *privacy.FilterConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return reflect.DeepEqual(normalizedFilterConfig(a), normalizedFilterConfig(b))
+}
+
+// normalizedFilterConfig returns a copy of config with empty slices set to nil
+func normalizedFilterConfig(config *privacy.FilterConfig) *privacy.FilterConfig {
+	normalized := config.Clone()
+	if len(normalized.CustomPatterns) == 0 {
+		normalized.CustomPatterns = nil
+	}
+	if len(normalized.CustomPatternSpecs) == 0 {
+		normalized.CustomPatternSpecs = nil
+	}
+	if len(normalized.LiteralDenylist) == 0 {
+		normalized.LiteralDenylist = nil
+	}
+	if len(normalized.Categories) == 0 {
+		normalized.Categories = nil
+	}
+	return normalized
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// isTOMLPath reports whether the config file at path should be read and written as TOML
+func isTOMLPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// unmarshalConfigData parses config file data in the format implied by path.
+// TOML is converted to JSON first so the json struct tags apply to both formats.
+func unmarshalConfigData(path string, data []byte, v interface{}) error {
+	jsonData, err := configJSON(path, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, v)
+}
+
+// unmarshalConfigDataStrict is unmarshalConfigData but fails on any field that v
+// has no place for, naming the field in the error
+func unmarshalConfigDataStrict(path string, data []byte, v interface{}) error {
+	jsonData, err := configJSON(path, data)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after the top-level value")
+	}
+	return nil
+}
+
+// configJSON returns config file data as JSON, converting it from TOML when path
+// names a TOML file
+func configJSON(path string, data []byte) ([]byte, error) {
+	if !isTOMLPath(path) {
+		return data, nil
+	}
+
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// marshalConfigData encodes v in the format implied by path
+func marshalConfigData(path string, v interface{}) ([]byte, error) {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil || !isTOMLPath(path) {
+		return jsonData, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(tomlValue(doc)); err != nil {
+		return nil, fmt.Errorf("failed to encode TOML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// tomlValue converts a decoded JSON value into one TOML can encode: numbers become
+// int64 or float64, and nulls, which TOML cannot represent, are dropped from tables
+func tomlValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		table := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			if item != nil {
+				table[k] = tomlValue(item)
+			}
+		}
+		return table
+	case []interface{}:
+		items := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			if item != nil {
+				items = append(items, tomlValue(item))
+			}
+		}
+		return items
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	default:
+		return value
+	}
+}
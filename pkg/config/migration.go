@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+)
+
+// CurrentConfigVersion is the schema version written by this release
+const CurrentConfigVersion = 1
+
+// providerRenames maps provider names accepted by unversioned configs to their current names
+var providerRenames = map[string]string{
+	"azure":  "azure_openai",
+	"claude": "anthropic",
+	"google": "gemini",
+}
+
+// Migrate upgrades a configuration written by an older release to the current schema version.
+// Configurations without a version are treated as version 0.
+func (c *Config) Migrate() error {
+	if c.Version > CurrentConfigVersion {
+		return fmt.Errorf("config version %d is newer than the supported version %d", c.Version, CurrentConfigVersion)
+	}
+
+	if c.Version < 1 {
+		migrateV0ToV1(c)
+	}
+
+	c.Version = CurrentConfigVersion
+	return nil
+}
+
+// migrateV0ToV1 renames the provider aliases accepted by unversioned configs
+func migrateV0ToV1(c *Config) {
+	if renamed, ok := providerRenames[c.DefaultProvider]; ok {
+		c.DefaultProvider = renamed
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate_V0Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeTestConfig(t, path, `{
+  "default_provider": "claude",
+  "anthropic": {"api_key": "sk-ant-test", "model": "claude-3-5-sonnet-20241022"}
+}`)
+
+	cfg, err := LoadConfigAndMigrate(path, true)
+	if err != nil {
+		t.Fatalf("LoadConfigAndMigrate returned error: %v", err)
+	}
+
+	if cfg.Version != CurrentConfigVersion {
+		t.Errorf("expected version %d, got %d", CurrentConfigVersion, cfg.Version)
+	}
+	if cfg.DefaultProvider != "anthropic" {
+		t.Errorf("expected default provider to be migrated to anthropic, got %q", cfg.DefaultProvider)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected migrated config to validate, got: %v", err)
+	}
+
+	// The rewritten file should be current and must not include merged defaults
+	raw, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("failed to read rewritten config: %v", err)
+	}
+	if raw.Version != CurrentConfigVersion || raw.DefaultProvider != "anthropic" {
+		t.Errorf("expected rewritten config to be migrated, got version %d provider %q", raw.Version, raw.DefaultProvider)
+	}
+	if raw.OpenAI != nil {
+		t.Error("expected rewritten config not to include merged defaults")
+	}
+}
+
+func TestMigrate_NewerVersion(t *testing.T) {
+	cfg := &Config{Version: CurrentConfigVersion + 1}
+	err := cfg.Migrate()
+	if err == nil {
+		t.Fatal("expected error for a config newer than the supported version")
+	}
+	if !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected error to mention newer version, got: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// modelCache represents the on-disk cache of models reported by a provider
+type modelCache struct {
+	Provider  string    `json:"provider"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Models    []string  `json:"models"`
+}
+
+// modelListResponse covers the OpenAI-style and Gemini-style model list responses
+type modelListResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// RefreshModels fetches the model list from the provider's models endpoint using the
+// configured API key and base URL, and caches the result under the config directory.
+// validateModelName consults the cache when present, so a failed refresh simply leaves
+// validation on the built-in heuristics.
+func (c *Config) RefreshModels(provider string) error {
+	pc, err := c.GetProviderConfig(provider)
+	if err != nil {
+		return err
+	}
+
+	endpoint, err := modelsEndpoint(provider, pc)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	setAuthHeaders(req, provider, pc.APIKey, pc)
+	setHeaders(req, c.EffectiveHeaders(provider))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read models response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("models request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var list modelListResponse
+	if err := json.Unmarshal(body, &list); err != nil {
+		return fmt.Errorf("failed to parse models response: %w", err)
+	}
+
+	var models []string
+	for _, m := range list.Data {
+		if m.ID != "" {
+			models = append(models, m.ID)
+		}
+	}
+	for _, m := range list.Models {
+		if m.Name != "" {
+			models = append(models, strings.TrimPrefix(m.Name, "models/"))
+		}
+	}
+
+	if len(models) == 0 {
+		return fmt.Errorf("no models returned from %s", provider)
+	}
+
+	return saveModelCache(provider, models)
+}
+
+// modelsEndpoint returns the URL of the models endpoint for the provider
+func modelsEndpoint(provider string, pc *ProviderConfig) (string, error) {
+	baseURL := strings.TrimSuffix(pc.BaseURL, "/")
+	if baseURL == "" {
+		return "", fmt.Errorf("%s base_url is not configured", provider)
+	}
+
+	switch provider {
+	case "gemini":
+		// The key is sent in the x-goog-api-key header, never in the URL
+		return baseURL + "/v1beta/models", nil
+	case "deepseek":
+		return baseURL + "/models", nil
+	case "openai_compatible":
+		if idx := strings.Index(baseURL, "/chat/completions"); idx != -1 {
+			return baseURL[:idx] + "/models", nil
+		}
+		return baseURL + "/v1/models", nil
+	default:
+		return baseURL + "/v1/models", nil
+	}
+}
+
+// getModelCachePath returns the cache file path for the provider's model list
+func getModelCachePath(provider string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "smart-suggestion", "models", provider+".json"), nil
+}
+
+// saveModelCache writes the provider's model list to the cache
+func saveModelCache(provider string, models []string) error {
+	cachePath, err := getModelCachePath(provider)
+	if err != nil {
+		return err
+	}
+
+	if err := CreateSecureDirectory(filepath.Dir(cachePath)); err != nil {
+		return fmt.Errorf("failed to create models cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(modelCache{
+		Provider:  provider,
+		FetchedAt: time.Now(),
+		Models:    models,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal models cache: %w", err)
+	}
+
+	if err := os.WriteFile(cachePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write models cache: %w", err)
+	}
+
+	return nil
+}
+
+// loadCachedModels returns the cached model list for the provider, or nil if none is cached
+func loadCachedModels(provider string) []string {
+	cachePath, err := getModelCachePath(provider)
+	if err != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil
+	}
+
+	var cache modelCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil
+	}
+
+	return cache.Models
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Provider identifies an AI provider by its configuration name
+type Provider string
+
+// Supported providers. The values match the config file keys and default_provider values.
+const (
+	ProviderOpenAI           Provider = "openai"
+	ProviderOpenAICompatible Provider = "openai_compatible"
+	ProviderAzure            Provider = "azure_openai"
+	ProviderAnthropic        Provider = "anthropic"
+	ProviderGemini           Provider = "gemini"
+	ProviderDeepSeek         Provider = "deepseek"
+	ProviderMistral          Provider = "mistral"
+	ProviderOpenRouter       Provider = "openrouter"
+	ProviderOllama           Provider = "ollama"
+)
+
+// Providers returns every supported provider in documentation order
+func Providers() []Provider {
+	return []Provider{
+		ProviderOpenAI,
+		ProviderOpenAICompatible,
+		ProviderAzure,
+		ProviderAnthropic,
+		ProviderGemini,
+		ProviderDeepSeek,
+		ProviderMistral,
+		ProviderOpenRouter,
+		ProviderOllama,
+	}
+}
+
+// ParseProvider returns the Provider with the given configuration name, or an error
+// if the name is neither a supported provider nor one added with RegisterProvider
+func ParseProvider(name string) (Provider, error) {
+	for _, p := range Providers() {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	if isRegisteredProvider(name) {
+		return Provider(name), nil
+	}
+	return "", fmt.Errorf("unsupported provider: %s", name)
+}
+
+// providerNamePattern matches the names RegisterProvider accepts
+var providerNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+var (
+	registryMu sync.RWMutex
+	// registeredProviders holds the defaults of the providers added with RegisterProvider
+	registeredProviders = map[string]ProviderConfig{}
+)
+
+// RegisterProvider adds a provider beyond the built-in ones, such as an internal LLM
+// gateway, so that name can be used wherever a provider name is accepted. Its settings
+// are read from the custom_providers block of that name, and defaults fills in the
+// settings the block leaves unset, as DefaultConfig does for built-in providers.
+// Names are lowercase letters, digits, '-' and '_', and must not be a built-in
+// provider. Registering a name again replaces its defaults.
+func RegisterProvider(name string, defaults ProviderConfig) error {
+	if !providerNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid provider name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	if slices.Contains(Providers(), Provider(name)) {
+		return fmt.Errorf("%s is a built-in provider", name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registeredProviders[name] = *cloneProviderConfig(&defaults)
+	return nil
+}
+
+// RegisteredProviders returns the names added with RegisterProvider, sorted
+func RegisteredProviders() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return slices.Sorted(maps.Keys(registeredProviders))
+}
+
+// isRegisteredProvider reports whether name was added with RegisterProvider
+func isRegisteredProvider(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registeredProviders[name]
+	return ok
+}
+
+// registeredProviderDefaults returns a copy of the defaults of every registered
+// provider, keyed by name, or nil when none are registered
+func registeredProviderDefaults() map[string]*ProviderConfig {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if len(registeredProviders) == 0 {
+		return nil
+	}
+
+	defaults := make(map[string]*ProviderConfig, len(registeredProviders))
+	for name, pc := range registeredProviders {
+		defaults[name] = cloneProviderConfig(&pc)
+	}
+	return defaults
+}
+
+// customAPIKeyEnvVar returns the conventional API key variable of a registered
+// provider, such as INTERNAL_LLM_API_KEY for internal-llm
+func customAPIKeyEnvVar(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_API_KEY"
+}
+
+// String returns the provider's configuration name
+func (p Provider) String() string {
+	return string(p)
+}
+
+// GetProviderConfigFor is GetProviderConfig for a typed provider
+func (c *Config) GetProviderConfigFor(p Provider) (*ProviderConfig, error) {
+	return c.GetProviderConfig(string(p))
+}
+
+// GetAPIKeyFor is GetAPIKey for a typed provider
+func (c *Config) GetAPIKeyFor(p Provider) (string, error) {
+	return c.GetAPIKey(string(p))
+}
+
+// GetModelFor is GetModel for a typed provider
+func (c *Config) GetModelFor(p Provider) (string, error) {
+	return c.GetModel(string(p))
+}
+
+// ValidateProviderAvailableFor is ValidateProviderAvailable for a typed provider
+func (c *Config) ValidateProviderAvailableFor(p Provider) error {
+	return c.ValidateProviderAvailable(string(p))
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProvider(t *testing.T) {
+	for _, p := range Providers() {
+		parsed, err := ParseProvider(p.String())
+		if err != nil || parsed != p {
+			t.Errorf("ParseProvider(%q) = %q, %v; want %q", p, parsed, err, p)
+		}
+		if !isValidProvider(string(p)) {
+			t.Errorf("expected %q to be a valid provider", p)
+		}
+	}
+
+	if p, _ := ParseProvider("azure_openai"); p != ProviderAzure {
+		t.Errorf("expected azure_openai to parse as ProviderAzure, got %q", p)
+	}
+
+	for _, name := range []string{"", "openia", "OpenAI", " openai", "azure"} {
+		if p, err := ParseProvider(name); err == nil {
+			t.Errorf("ParseProvider(%q) = %q; want error", name, p)
+		}
+	}
+}
+
+func TestTypedProviderGetters(t *testing.T) {
+	cfg := &Config{
+		OpenAI:    &ProviderConfig{APIKey: "openai-key", Model: "gpt-4o"},
+		Anthropic: &ProviderConfig{APIKey: "anthropic-key"},
+		AzureOpenAI: &AzureOpenAIConfig{
+			ProviderConfig: ProviderConfig{APIKey: "azure-key"},
+			DeploymentName: "my-deployment",
+		},
+	}
+
+	for _, name := range []string{"openai", "anthropic", "azure_openai", "gemini"} {
+		p, err := ParseProvider(name)
+		if err != nil {
+			t.Fatalf("ParseProvider(%q) returned error: %v", name, err)
+		}
+
+		key, keyErr := cfg.GetAPIKeyFor(p)
+		wantKey, wantKeyErr := cfg.GetAPIKey(name)
+		if key != wantKey || (keyErr == nil) != (wantKeyErr == nil) {
+			t.Errorf("%s: GetAPIKeyFor = %q, %v; GetAPIKey = %q, %v", name, key, keyErr, wantKey, wantKeyErr)
+		}
+
+		model, modelErr := cfg.GetModelFor(p)
+		wantModel, wantModelErr := cfg.GetModel(name)
+		if model != wantModel || (modelErr == nil) != (wantModelErr == nil) {
+			t.Errorf("%s: GetModelFor = %q, %v; GetModel = %q, %v", name, model, modelErr, wantModel, wantModelErr)
+		}
+
+		if (cfg.ValidateProviderAvailableFor(p) == nil) != (cfg.ValidateProviderAvailable(name) == nil) {
+			t.Errorf("%s: ValidateProviderAvailableFor disagrees with ValidateProviderAvailable", name)
+		}
+	}
+
+	if pc, err := cfg.GetProviderConfigFor(ProviderOpenAI); err != nil || pc != cfg.OpenAI {
+		t.Errorf("GetProviderConfigFor(ProviderOpenAI) = %v, %v; want the OpenAI config", pc, err)
+	}
+	if model, err := cfg.GetModelFor(ProviderAzure); err != nil || model != "my-deployment" {
+		t.Errorf("GetModelFor(ProviderAzure) = %q, %v; want deployment name", model, err)
+	}
+	if _, err := cfg.GetProviderConfigFor(ProviderGemini); err == nil {
+		t.Error("expected error for an unconfigured provider")
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		delete(registeredProviders, "internal-llm")
+	})
+
+	cfg := &Config{CustomProviders: map[string]*ProviderConfig{"internal-llm": {APIKey: "gateway-key"}}}
+	if isValidProvider("internal-llm") || cfg.ValidateProviderAvailable("internal-llm") == nil {
+		t.Fatal("expected an unregistered provider to be rejected")
+	}
+	if errors := cfg.validationErrors(); !errors.hasField("custom_providers.internal-llm") {
+		t.Errorf("expected a warning for the unregistered provider block, got %v", errors)
+	}
+
+	if err := RegisterProvider("openai", ProviderConfig{}); err == nil {
+		t.Error("expected a built-in provider name to be rejected")
+	}
+	if err := RegisterProvider("Internal LLM", ProviderConfig{}); err == nil {
+		t.Error("expected an invalid provider name to be rejected")
+	}
+	if err := RegisterProvider("internal-llm", ProviderConfig{BaseURL: "https://llm.internal.example.com", Model: "gateway-large"}); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+
+	if p, err := ParseProvider("internal-llm"); err != nil || p != "internal-llm" {
+		t.Errorf("expected registered provider to parse, got %q, %v", p, err)
+	}
+	if key, err := cfg.GetAPIKey("internal-llm"); err != nil || key != "gateway-key" {
+		t.Errorf("expected the custom provider's API key, got %q, %v", key, err)
+	}
+	if env := cfg.APIKeyEnvVar("internal-llm"); env != "INTERNAL_LLM_API_KEY" {
+		t.Errorf("expected a conventional API key variable, got %q", env)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg.DefaultProvider = "internal-llm"
+	if err := cfg.SaveConfig(path); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read saved config: %v", err)
+	}
+	if !strings.Contains(string(data), `"custom_providers"`) {
+		t.Errorf("expected custom providers to be saved, got %s", data)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	pc, err := loaded.GetProviderConfig("internal-llm")
+	if err != nil {
+		t.Fatalf("expected the custom provider to be configured, got %v", err)
+	}
+	if pc.APIKey != "gateway-key" || pc.BaseURL != "https://llm.internal.example.com" || pc.Model != "gateway-large" {
+		t.Errorf("expected saved settings merged with the registered defaults, got %+v", pc)
+	}
+	if err := loaded.ValidateProviderAvailable("internal-llm"); err != nil {
+		t.Errorf("expected the custom provider to be available, got %v", err)
+	}
+	if errors := loaded.validationErrors(); errors.hasField("default_provider") || errors.hasField("custom_providers.internal-llm") {
+		t.Errorf("expected the registered provider to validate, got %v", errors)
+	}
+	if names := loaded.ListConfiguredProviders(); names[len(names)-1] != "internal-llm" {
+		t.Errorf("expected the custom provider to be listed last, got %v", names)
+	}
+
+	loaded.CustomProviders["internal-llm"].APIKey = ""
+	if err := loaded.ValidateProviderAvailable("internal-llm"); err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("expected a missing API key to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// reloadMu guards every config's overrides and serializes Reload, SetOverride and
+// Snapshot, so that a daemon can reload a shared config while other goroutines take
+// snapshots of it. A single lock keeps Config copyable, as Clone relies on.
+var reloadMu sync.RWMutex
+
+// SetOverride sets the setting at path, a dotted JSON path such as default_provider or
+// openai.model as reported by Diff, to value and keeps it across Reload. A nil value
+// removes the setting. Paths the config schema does not define and values of the
+// wrong type are rejected without changing the config.
+func (c *Config) SetOverride(path string, value interface{}) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if err := applyOverrides(c, map[string]interface{}{path: value}); err != nil {
+		return err
+	}
+	if c.overrides == nil {
+		c.overrides = map[string]interface{}{}
+	}
+	c.overrides[path] = deepCopyValue(value)
+	return nil
+}
+
+// ClearOverrides forgets every override set with SetOverride. The current settings
+// are kept; the next Reload reads them from the file alone.
+func (c *Config) ClearOverrides() {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	c.overrides = nil
+}
+
+// Overrides returns the paths set with SetOverride, sorted
+func (c *Config) Overrides() []string {
+	reloadMu.RLock()
+	defer reloadMu.RUnlock()
+	return slices.Sorted(maps.Keys(c.overrides))
+}
+
+// Reload reads the config file at path again as LoadConfig does, merging defaults,
+// and then re-applies the overrides set with SetOverride. An empty path reloads the
+// files in SourcePaths, layered again as LoadConfigLayered does when there are
+// several. On error the config is left unchanged.
+func (c *Config) Reload(path string) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	var loaded *Config
+	var err error
+	if path == "" && len(c.sourcePaths) > 1 {
+		loaded, err = LoadConfigLayered(c.sourcePaths...)
+	} else {
+		if path == "" {
+			path = c.SourcePath()
+		}
+		loaded, err = LoadConfig(path)
+	}
+	if err != nil {
+		return err
+	}
+	loaded.overrides = c.overrides
+	if err := applyOverrides(loaded, c.overrides); err != nil {
+		return fmt.Errorf("failed to re-apply overrides: %w", err)
+	}
+
+	*c = *loaded
+	return nil
+}
+
+// Snapshot returns a deep copy of the config taken while no Reload or SetOverride is
+// in progress. Goroutines reading a config that another goroutine reloads should read
+// from a snapshot instead of the shared config.
+func (c *Config) Snapshot() *Config {
+	reloadMu.RLock()
+	defer reloadMu.RUnlock()
+	return c.Clone()
+}
+
+// applyOverrides sets each dotted path in overrides on cfg. The config is encoded
+// as JSON, edited and decoded again, rejecting fields the schema does not define.
+func applyOverrides(cfg *Config, overrides map[string]interface{}) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	fields, err := configFields(cfg)
+	if err != nil {
+		return err
+	}
+	for _, path := range slices.Sorted(maps.Keys(overrides)) {
+		if err := setField(fields, path, deepCopyValue(overrides[path])); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	var updated Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&updated); err != nil {
+		return fmt.Errorf("invalid override: %w", err)
+	}
+
+	updated.sourcePaths = cfg.sourcePaths
+	updated.overrides = cfg.overrides
+	updated.defaulted = maps.Clone(cfg.defaulted)
+	for path := range overrides {
+		delete(updated.defaulted, overrideProvider(path))
+	}
+	*cfg = updated
+	return nil
+}
+
+// overrideProvider returns the name of the provider block a dotted override path
+// sets, such as ollama for ollama.model, or the path's first key otherwise
+func overrideProvider(path string) string {
+	keys := strings.SplitN(path, ".", 3)
+	if keys[0] == "custom_providers" && len(keys) > 1 {
+		return keys[1]
+	}
+	return keys[0]
+}
+
+// setField sets the value at a dotted path in decoded JSON fields, creating the
+// objects along the way. A nil value deletes the setting.
+func setField(fields map[string]interface{}, path string, value interface{}) error {
+	keys := strings.Split(path, ".")
+	if slices.Contains(keys, "") {
+		return fmt.Errorf("invalid override path %q", path)
+	}
+
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := fields[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			fields[key] = next
+		}
+		fields = next
+	}
+
+	last := keys[len(keys)-1]
+	if value == nil {
+		delete(fields, last)
+	} else {
+		fields[last] = value
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRequestTimeout is the timeout used for provider requests
+const DefaultRequestTimeout = 30 * time.Second
+
+// ResolvedProvider holds the final settings needed to call a provider, with defaults
+// applied and every required value present
+type ResolvedProvider struct {
+	Provider string
+	// BaseURL is normalized as by NormalizeBaseURL. For azure_openai it is derived
+	// from resource_name when base_url is not set.
+	BaseURL string
+	// Model is the model name, or the deployment name for azure_openai
+	Model   string
+	APIKey  string
+	Headers map[string]string
+	Timeout time.Duration
+	// Retry is a copy of the provider's retry settings, or nil when requests are not retried
+	Retry *RetryConfig
+}
+
+// ResolveProvider returns the ready-to-use settings for provider in one call. Missing
+// base URLs and models fall back to DefaultConfig, headers are merged as by
+// EffectiveHeaders and the API key is resolved by ResolveAPIKey. When the provider is
+// unusable the error lists every missing or invalid setting.
+func (c *Config) ResolveProvider(provider string) (*ResolvedProvider, error) {
+	if !isValidProvider(provider) {
+		return nil, fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	pc := c.providerConfigs()[provider]
+	if pc == nil {
+		return nil, fmt.Errorf("%s provider not configured", provider)
+	}
+	if !pc.IsEnabled() {
+		return nil, fmt.Errorf("%s provider is disabled", provider)
+	}
+
+	var problems ValidationErrors
+	for _, err := range c.providerValidationErrors(provider) {
+		if !err.Warning {
+			problems = append(problems, err)
+		}
+	}
+
+	resolved := &ResolvedProvider{
+		Provider: provider,
+		Headers:  c.EffectiveHeaders(provider),
+		Timeout:  DefaultRequestTimeout,
+	}
+	if pc.Retry != nil {
+		retry := *pc.Retry
+		resolved.Retry = &retry
+	}
+
+	baseURL := pc.BaseURL
+	if provider == "azure_openai" && baseURL == "" && c.AzureOpenAI.ResourceName != "" {
+		baseURL = fmt.Sprintf("https://%s.openai.azure.com", c.AzureOpenAI.ResourceName)
+	}
+	if baseURL == "" {
+		baseURL = DefaultConfig().providerConfigs()[provider].BaseURL
+	}
+	if baseURL == "" {
+		field := provider + ".base_url"
+		message := "base_url is not configured"
+		if provider == "azure_openai" {
+			message = "base_url or resource_name is not configured"
+		}
+		problems = append(problems, ValidationError{Field: field, Message: message})
+	}
+	resolved.BaseURL = NormalizeBaseURL(baseURL, c.BaseURLRequireScheme)
+
+	if model, err := c.GetModel(provider); err != nil {
+		field := provider + ".model"
+		if provider == "azure_openai" {
+			field = provider + ".deployment_name"
+		}
+		if !problems.hasField(field) {
+			problems = append(problems, ValidationError{Field: field, Message: err.Error()})
+		}
+	} else {
+		resolved.Model = model
+	}
+
+	// Ollama runs locally and does not require an API key
+	if apiKey, err := c.ResolveAPIKey(provider); err == nil {
+		resolved.APIKey = apiKey
+	} else if provider != "ollama" {
+		problems = append(problems, ValidationError{
+			Field:   provider + ".api_key",
+			Message: fmt.Sprintf("api_key is not configured and $%s is not set", c.APIKeyEnvVar(provider)),
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("cannot resolve provider %s: %w", provider, problems)
+	}
+	return resolved, nil
+}
+
+// providerValidationErrors runs the validation checks for a single provider block
+func (c *Config) providerValidationErrors(provider string) ValidationErrors {
+	if provider == "azure_openai" {
+		return validateAzureOpenAIConfig(c.AzureOpenAI)
+	}
+	return validateProviderConfig(provider, c.providerConfigs()[provider])
+}
+
+// hasField reports whether any error is for field
+func (e ValidationErrors) hasField(field string) bool {
+	for _, err := range e {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveProvider(t *testing.T) {
+	cfg := &Config{
+		GlobalHeaders: map[string]string{"x-trace-id": "shell"},
+		Anthropic: &ProviderConfig{
+			APIKey:     "sk-ant-test",
+			BaseURL:    "https://api.anthropic.com/",
+			APIVersion: "2023-06-01",
+			Headers:    map[string]string{"X-Team": "infra"},
+			Retry:      &RetryConfig{MaxAttempts: 3, InitialBackoffMs: 100, MaxBackoffMs: 1000, Multiplier: 2},
+		},
+	}
+
+	resolved, err := cfg.ResolveProvider("anthropic")
+	if err != nil {
+		t.Fatalf("ResolveProvider returned error: %v", err)
+	}
+	if resolved.BaseURL != "https://api.anthropic.com" {
+		t.Errorf("expected normalized base URL, got %q", resolved.BaseURL)
+	}
+	if resolved.Model != DefaultConfig().Anthropic.Model {
+		t.Errorf("expected default model, got %q", resolved.Model)
+	}
+	if resolved.APIKey != "sk-ant-test" {
+		t.Errorf("expected API key from config, got %q", resolved.APIKey)
+	}
+	if resolved.Headers["X-Trace-Id"] != "shell" || resolved.Headers["X-Team"] != "infra" {
+		t.Errorf("expected effective headers, got %v", resolved.Headers)
+	}
+	if resolved.Timeout != DefaultRequestTimeout {
+		t.Errorf("expected default timeout, got %v", resolved.Timeout)
+	}
+	if resolved.Retry == nil || *resolved.Retry != *cfg.Anthropic.Retry || resolved.Retry == cfg.Anthropic.Retry {
+		t.Errorf("expected a copy of the retry settings, got %+v", resolved.Retry)
+	}
+
+	ollama := &Config{Ollama: &ProviderConfig{}}
+	resolved, err = ollama.ResolveProvider("ollama")
+	if err != nil {
+		t.Fatalf("expected ollama to resolve without an API key, got: %v", err)
+	}
+	if resolved.BaseURL != DefaultConfig().Ollama.BaseURL {
+		t.Errorf("expected default ollama base URL, got %q", resolved.BaseURL)
+	}
+
+	azure := &Config{AzureOpenAI: &AzureOpenAIConfig{
+		ProviderConfig: ProviderConfig{APIKey: "azure-key"},
+		ResourceName:   "my-resource",
+		DeploymentName: "gpt-4o-prod",
+	}}
+	resolved, err = azure.ResolveProvider("azure_openai")
+	if err != nil {
+		t.Fatalf("ResolveProvider returned error for azure: %v", err)
+	}
+	if resolved.BaseURL != "https://my-resource.openai.azure.com" || resolved.Model != "gpt-4o-prod" {
+		t.Errorf("expected azure URL from resource name and deployment as model, got %+v", resolved)
+	}
+}
+
+func TestResolveProvider_Missing(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	cfg := &Config{OpenAI: &ProviderConfig{BaseURL: "ftp://example.com"}}
+
+	resolved, err := cfg.ResolveProvider("openai")
+	if err == nil {
+		t.Fatalf("expected error, got %+v", resolved)
+	}
+	for _, want := range []string{"openai.api_key", "openai.base_url"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %s, got: %v", want, err)
+		}
+	}
+
+	cfg = &Config{AzureOpenAI: &AzureOpenAIConfig{ProviderConfig: ProviderConfig{APIKey: "azure-key"}}}
+	_, err = cfg.ResolveProvider("azure_openai")
+	if err == nil {
+		t.Fatal("expected error for azure without deployment or endpoint")
+	}
+	if strings.Count(err.Error(), "azure_openai.deployment_name") != 1 || !strings.Contains(err.Error(), "azure_openai.base_url") {
+		t.Errorf("expected one deployment_name and one base_url error, got: %v", err)
+	}
+
+	if _, err := (&Config{}).ResolveProvider("gemini"); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("expected not configured error, got %v", err)
+	}
+	disabled := false
+	if _, err := (&Config{Gemini: &ProviderConfig{APIKey: "key", Enabled: &disabled}}).ResolveProvider("gemini"); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected disabled error, got %v", err)
+	}
+	if _, err := (&Config{}).ResolveProvider("unknown"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "conventional-key")
+	t.Setenv("MY_OPENAI_KEY", "custom-key")
+
+	cfg := &Config{OpenAI: &ProviderConfig{}}
+	if key, err := cfg.ResolveAPIKey("openai"); err != nil || key != "conventional-key" {
+		t.Errorf("expected conventional env var to be used, got %q, %v", key, err)
+	}
+
+	cfg.OpenAI.APIKeyEnv = "MY_OPENAI_KEY"
+	if got := cfg.APIKeyEnvVar("openai"); got != "MY_OPENAI_KEY" {
+		t.Errorf("expected custom env var name, got %q", got)
+	}
+	if key, err := cfg.ResolveAPIKey("openai"); err != nil || key != "custom-key" {
+		t.Errorf("expected custom env var to be used, got %q, %v", key, err)
+	}
+
+	cfg.OpenAI.APIKey = "config-key"
+	if key, _ := cfg.ResolveAPIKey("openai"); key != "config-key" {
+		t.Errorf("expected config key to take precedence, got %q", key)
+	}
+
+	t.Setenv("MY_OPENAI_KEY", "")
+	cfg.OpenAI.APIKey = ""
+	_, err := cfg.ResolveAPIKey("openai")
+	if err == nil || !strings.Contains(err.Error(), "$MY_OPENAI_KEY") {
+		t.Errorf("expected error naming the custom env var, got %v", err)
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "anthropic-env-key")
+	resolved, err := (&Config{Anthropic: &ProviderConfig{APIVersion: "2023-06-01"}}).ResolveProvider("anthropic")
+	if err != nil || resolved.APIKey != "anthropic-env-key" {
+		t.Errorf("expected ResolveProvider to fall back to the env var, got %+v, %v", resolved, err)
+	}
+
+	if _, err := cfg.ResolveAPIKey("unknown"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
@@ -2,14 +2,23 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net"
 	"net/url"
+	"os"
+	"slices"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // ValidationError represents a configuration validation error
 type ValidationError struct {
 	Field   string
 	Message string
+	// Warning marks advisory checks, such as model name heuristics, that may be
+	// false positives
+	Warning bool
 }
 
 func (e *ValidationError) Error() string {
@@ -34,8 +43,49 @@ func (e ValidationErrors) Error() string {
 	return fmt.Sprintf("multiple validation errors: %s", strings.Join(messages, "; "))
 }
 
-// Validate validates the configuration and returns any validation errors
+// Validate validates the configuration and returns any validation errors.
+// Advisory checks are reported as errors too, unless SMART_SUGGESTION_VALIDATE_WARN_ONLY
+// is set to true.
 func (c *Config) Validate() error {
+	warnOnly := os.Getenv("SMART_SUGGESTION_VALIDATE_WARN_ONLY") == "true"
+
+	var errors ValidationErrors
+	for _, err := range c.validationErrors() {
+		if err.Warning && warnOnly {
+			continue
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+// ValidateForCI validates the configuration and returns the messages split into hard
+// errors and advisory warnings. It never panics.
+func (c *Config) ValidateForCI() (errors, warnings []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			errors = append(errors, fmt.Sprintf("validation panicked: %v", r))
+		}
+	}()
+
+	for _, err := range c.validationErrors() {
+		if err.Warning {
+			warnings = append(warnings, err.Error())
+		} else {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	return errors, warnings
+}
+
+// validationErrors runs every validation check, including advisory ones
+func (c *Config) validationErrors() ValidationErrors {
 	var errors ValidationErrors
 
 	// Validate general settings
@@ -43,58 +93,132 @@ func (c *Config) Validate() error {
 		if !isValidProvider(c.DefaultProvider) {
 			errors = append(errors, ValidationError{
 				Field:   "default_provider",
-				Message: fmt.Sprintf("invalid provider '%s', must be one of: openai, azure_openai, anthropic, gemini, deepseek", c.DefaultProvider),
+				Message: fmt.Sprintf("invalid provider '%s', must be one of: openai, openai_compatible, azure_openai, anthropic, gemini, deepseek, mistral, openrouter, ollama", c.DefaultProvider),
 			})
 		}
 	}
 
+	errors = append(errors, validateHeaders("global_headers", c.GlobalHeaders)...)
 
-	// Validate provider configurations
-	if c.OpenAI != nil {
+	// Validate provider configurations. Disabled providers are skipped.
+	if c.OpenAI.IsEnabled() {
 		if err := validateProviderConfig("openai", c.OpenAI); err != nil {
 			errors = append(errors, err...)
 		}
 	}
 
-	if c.OpenAICompatible != nil {
+	if c.OpenAICompatible.IsEnabled() {
 		if err := validateProviderConfig("openai_compatible", c.OpenAICompatible); err != nil {
 			errors = append(errors, err...)
 		}
 	}
 
-	if c.AzureOpenAI != nil {
+	if c.AzureOpenAI != nil && c.AzureOpenAI.IsEnabled() {
 		if err := validateAzureOpenAIConfig(c.AzureOpenAI); err != nil {
 			errors = append(errors, err...)
 		}
 	}
 
-	if c.Anthropic != nil {
+	if c.Anthropic.IsEnabled() {
 		if err := validateProviderConfig("anthropic", c.Anthropic); err != nil {
 			errors = append(errors, err...)
 		}
 	}
 
-	if c.Gemini != nil {
+	if c.Gemini.IsEnabled() {
 		if err := validateProviderConfig("gemini", c.Gemini); err != nil {
 			errors = append(errors, err...)
 		}
 	}
 
-	if c.DeepSeek != nil {
+	if c.DeepSeek.IsEnabled() {
 		if err := validateProviderConfig("deepseek", c.DeepSeek); err != nil {
 			errors = append(errors, err...)
 		}
 	}
 
-	if len(errors) > 0 {
-		return errors
+	if c.Mistral.IsEnabled() {
+		if err := validateProviderConfig("mistral", c.Mistral); err != nil {
+			errors = append(errors, err...)
+		}
 	}
 
-	return nil
+	if c.OpenRouter.IsEnabled() {
+		if err := validateProviderConfig("openrouter", c.OpenRouter); err != nil {
+			errors = append(errors, err...)
+		}
+	}
+
+	if c.Ollama.IsEnabled() {
+		if err := validateProviderConfig("ollama", c.Ollama); err != nil {
+			errors = append(errors, err...)
+		}
+	}
+
+	// Blocks for providers this program has not registered cannot be used
+	for _, name := range slices.Sorted(maps.Keys(c.CustomProviders)) {
+		if !isRegisteredProvider(name) {
+			errors = append(errors, ValidationError{
+				Field:   "custom_providers." + name,
+				Message: fmt.Sprintf("provider '%s' is not registered and will be ignored", name),
+				Warning: true,
+			})
+		} else if c.CustomProviders[name].IsEnabled() {
+			errors = append(errors, validateProviderConfig(name, c.CustomProviders[name])...)
+		}
+	}
+
+	if c.RequireHTTPS {
+		errors = append(errors, c.httpsErrors()...)
+	}
+
+	return errors
 }
 
-// ValidateProviderAvailable validates that the specified provider is configured and has an API key
+// httpsErrors reports the base URLs of enabled providers that use plain http:// for a
+// host other than a loopback address, for configs with RequireHTTPS set
+func (c *Config) httpsErrors() ValidationErrors {
+	var errors ValidationErrors
+	configs := c.providerConfigs()
+	for _, provider := range c.ListConfiguredProviders() {
+		pc := configs[provider]
+		fields := []struct{ name, url string }{{"base_url", pc.BaseURL}, {"embedding_base_url", pc.EmbeddingBaseURL}}
+		for _, field := range fields {
+			if err := validateHTTPS(field.url); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   provider + "." + field.name,
+					Message: err.Error(),
+				})
+			}
+		}
+	}
+	return errors
+}
+
+// validateHTTPS rejects http:// URLs unless their host is localhost or a loopback IP
+func validateHTTPS(urlString string) error {
+	parsedURL, err := url.Parse(urlString)
+	if err != nil || !strings.EqualFold(parsedURL.Scheme, "http") {
+		return nil
+	}
+
+	host := parsedURL.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("require_https is set, so '%s' must use https:// (plain http is only allowed for loopback hosts)", urlString)
+}
+
+// ValidateProviderAvailable validates that the specified provider is configured, enabled
+// and has an API key
 func (c *Config) ValidateProviderAvailable(provider string) error {
+	if pc := c.providerConfigs()[provider]; pc != nil && !pc.IsEnabled() {
+		return fmt.Errorf("%s provider is disabled", provider)
+	}
+
 	switch provider {
 	case "openai":
 		if c.OpenAI == nil {
@@ -141,13 +265,51 @@ func (c *Config) ValidateProviderAvailable(provider string) error {
 		if c.DeepSeek.APIKey == "" {
 			return fmt.Errorf("DeepSeek API key not configured")
 		}
+	case "mistral":
+		if c.Mistral == nil {
+			return fmt.Errorf("Mistral provider not configured")
+		}
+		if c.Mistral.APIKey == "" {
+			return fmt.Errorf("Mistral API key not configured")
+		}
+	case "openrouter":
+		if c.OpenRouter == nil {
+			return fmt.Errorf("OpenRouter provider not configured")
+		}
+		if c.OpenRouter.APIKey == "" {
+			return fmt.Errorf("OpenRouter API key not configured")
+		}
+	case "ollama":
+		// Ollama runs locally and does not require an API key
+		if c.Ollama == nil {
+			return fmt.Errorf("Ollama provider not configured")
+		}
 	default:
-		return fmt.Errorf("unsupported provider: %s", provider)
+		if !isRegisteredProvider(provider) {
+			return fmt.Errorf("unsupported provider: %s", provider)
+		}
+		if c.CustomProviders[provider] == nil {
+			return fmt.Errorf("%s provider not configured", provider)
+		}
+		if c.CustomProviders[provider].APIKey == "" {
+			return fmt.Errorf("%s API key not configured", provider)
+		}
 	}
 
 	return nil
 }
 
+// ValidateAllAvailable checks every configured provider and returns a map from provider
+// name to nil when it is usable, or the error from ValidateProviderAvailable otherwise.
+// Providers without a configuration block and disabled providers are omitted.
+func (c *Config) ValidateAllAvailable() map[string]error {
+	results := map[string]error{}
+	for _, provider := range c.ListConfiguredProviders() {
+		results[provider] = c.ValidateProviderAvailable(provider)
+	}
+	return results
+}
+
 // validateProviderConfig validates a basic provider configuration
 func validateProviderConfig(providerName string, config *ProviderConfig) ValidationErrors {
 	var errors ValidationErrors
@@ -168,7 +330,8 @@ func validateProviderConfig(providerName string, config *ProviderConfig) Validat
 			errors = append(errors, ValidationError{
 				Field: prefix + ".base_url",
 				Message: fmt.Sprintf("base_url '%s' ends with version prefix (e.g., /v4). "+
-					"The provider auto-appends '/v1/chat/completions' when no version component is present. "+
+					"The provider auto-appends '/v1/chat/completions' when no version component is present, "+
+					"so drop the version suffix for standard APIs. "+
 					"For non-standard APIs, use the full URL path including '/chat/completions'. "+
 					"Example: https://open.bigmodel.cn/api/paas/v4/chat/completions",
 					config.BaseURL),
@@ -176,12 +339,71 @@ func validateProviderConfig(providerName string, config *ProviderConfig) Validat
 		}
 	}
 
+	// Validate embedding base URL if provided
+	if config.EmbeddingBaseURL != "" {
+		if err := validateURL(config.EmbeddingBaseURL); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".embedding_base_url",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if config.APIKeyEnv != "" && !isValidEnvVarName(config.APIKeyEnv) {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".api_key_env",
+			Message: fmt.Sprintf("'%s' is not a valid environment variable name", config.APIKeyEnv),
+		})
+	}
+
+	if length := utf8.RuneCountInString(config.SystemPrompt); length > MaxSystemPromptLength {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".system_prompt",
+			Message: fmt.Sprintf("system_prompt is %d characters long, the limit is %d", length, MaxSystemPromptLength),
+		})
+	}
+
+	errors = append(errors, validateHeaders(prefix+".headers", config.Headers)...)
+
+	if config.Retry != nil {
+		errors = append(errors, validateRetryConfig(prefix+".retry", config.Retry)...)
+	}
+
+	// Gemini and DeepSeek clients append their own versioned paths
+	if (providerName == "gemini" || providerName == "deepseek") && hasVersionSegment(config.BaseURL) {
+		errors = append(errors, ValidationError{
+			Field: prefix + ".base_url",
+			Message: fmt.Sprintf("base_url '%s' ends with a version segment. "+
+				"The %s client appends %s itself, so use the host only, e.g. %s",
+				config.BaseURL, providerName, providerPathSuffix(providerName),
+				DefaultConfig().providerConfigs()[providerName].BaseURL),
+			Warning: true,
+		})
+	}
+
+	// Anthropic sends api_version as the anthropic-version header
+	if providerName == "anthropic" {
+		if config.APIVersion == "" {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".api_version",
+				Message: "api_version is empty; Anthropic requires an anthropic-version header such as 2023-06-01",
+				Warning: true,
+			})
+		} else if !isValidAzureAPIVersion(config.APIVersion) {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".api_version",
+				Message: "invalid API version format, should be in format YYYY-MM-DD",
+			})
+		}
+	}
+
 	// Validate model name if provided
 	if config.Model != "" {
 		if err := validateModelName(providerName, config.Model); err != nil {
 			errors = append(errors, ValidationError{
 				Field:   prefix + ".model",
 				Message: err.Error(),
+				Warning: true,
 			})
 		}
 	}
@@ -189,6 +411,47 @@ func validateProviderConfig(providerName string, config *ProviderConfig) Validat
 	return errors
 }
 
+// MaxSystemPromptLength is the longest system_prompt, in characters, that validation
+// accepts. Longer prompts are more likely a file pasted by mistake than a prompt.
+const MaxSystemPromptLength = 32 * 1024
+
+// validateRetryConfig checks that retry settings describe a usable backoff
+func validateRetryConfig(field string, retry *RetryConfig) ValidationErrors {
+	var errors ValidationErrors
+
+	if retry.MaxAttempts < 1 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".max_attempts",
+			Message: fmt.Sprintf("max_attempts must be at least 1, got %d", retry.MaxAttempts),
+		})
+	}
+	if retry.InitialBackoffMs < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".initial_backoff_ms",
+			Message: fmt.Sprintf("initial_backoff_ms must not be negative, got %d", retry.InitialBackoffMs),
+		})
+	}
+	if retry.MaxBackoffMs < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".max_backoff_ms",
+			Message: fmt.Sprintf("max_backoff_ms must not be negative, got %d", retry.MaxBackoffMs),
+		})
+	} else if retry.MaxBackoffMs > 0 && retry.MaxBackoffMs < retry.InitialBackoffMs {
+		errors = append(errors, ValidationError{
+			Field:   field + ".max_backoff_ms",
+			Message: fmt.Sprintf("max_backoff_ms (%d) is less than initial_backoff_ms (%d)", retry.MaxBackoffMs, retry.InitialBackoffMs),
+		})
+	}
+	if retry.Multiplier < 1 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".multiplier",
+			Message: fmt.Sprintf("multiplier must be at least 1, got %g", retry.Multiplier),
+		})
+	}
+
+	return errors
+}
+
 // validateAzureOpenAIConfig validates Azure OpenAI specific configuration
 func validateAzureOpenAIConfig(config *AzureOpenAIConfig) ValidationErrors {
 	var errors ValidationErrors
@@ -204,6 +467,24 @@ func validateAzureOpenAIConfig(config *AzureOpenAIConfig) ValidationErrors {
 		})
 	}
 
+	if config.ResourceName != "" {
+		if err := validateAzureResourceName(config.ResourceName); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "azure_openai.resource_name",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if config.DeploymentName != "" {
+		if err := validateAzureDeploymentName(config.DeploymentName); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "azure_openai.deployment_name",
+				Message: err.Error(),
+			})
+		}
+	}
+
 	if config.DeploymentName == "" && config.APIKey != "" {
 		errors = append(errors, ValidationError{
 			Field:   "azure_openai.deployment_name",
@@ -211,6 +492,16 @@ func validateAzureOpenAIConfig(config *AzureOpenAIConfig) ValidationErrors {
 		})
 	}
 
+	// Requests use deployment_name, so model is informational, but it is still shown
+	// to users and should name an OpenAI model
+	if config.Model != "" && validateModelName("openai", config.Model) != nil {
+		errors = append(errors, ValidationError{
+			Field:   "azure_openai.model",
+			Message: fmt.Sprintf("model '%s' does not look like an OpenAI model (expected format: gpt-*); Azure requests use deployment_name, model is only informational", config.Model),
+			Warning: true,
+		})
+	}
+
 	if config.APIVersion != "" {
 		if !isValidAzureAPIVersion(config.APIVersion) {
 			errors = append(errors, ValidationError{
@@ -223,6 +514,115 @@ func validateAzureOpenAIConfig(config *AzureOpenAIConfig) ValidationErrors {
 	return errors
 }
 
+// validateHeaders checks that every header name is a valid HTTP token and that no value
+// contains control characters, which would otherwise allow injecting extra headers
+func validateHeaders(field string, headers map[string]string) ValidationErrors {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errors ValidationErrors
+	for _, name := range names {
+		if !isValidHeaderName(name) {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid header name '%s', must be a non-empty HTTP token without spaces or separators", name),
+			})
+			continue
+		}
+		if !isValidHeaderValue(headers[name]) {
+			errors = append(errors, ValidationError{
+				Field:   field + "." + name,
+				Message: "header value must not contain control characters such as CR or LF",
+			})
+		}
+	}
+	return errors
+}
+
+// isValidHeaderName reports whether name is an RFC 7230 token
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case isASCIIAlphanumeric(c):
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isValidHeaderValue reports whether value has no control characters other than tab
+func isValidHeaderValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	// azureNameMinLength and azureNameMaxLength bound resource and deployment names
+	azureNameMinLength = 2
+	azureNameMaxLength = 64
+)
+
+// validateAzureResourceName checks a resource name against Azure's custom subdomain
+// rules: 2-64 letters, digits and hyphens, not starting or ending with a hyphen
+func validateAzureResourceName(name string) error {
+	if len(name) < azureNameMinLength || len(name) > azureNameMaxLength {
+		return fmt.Errorf("resource name '%s' must be between %d and %d characters", name, azureNameMinLength, azureNameMaxLength)
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !isASCIIAlphanumeric(c) && c != '-' {
+			return fmt.Errorf("resource name '%s' contains '%c'; only letters, digits and hyphens are allowed", name, c)
+		}
+	}
+	if name[0] == '-' || name[len(name)-1] == '-' {
+		return fmt.Errorf("resource name '%s' must not start or end with a hyphen", name)
+	}
+	return nil
+}
+
+// validateAzureDeploymentName checks a deployment name against Azure's rules:
+// 2-64 letters, digits, hyphens, underscores and periods
+func validateAzureDeploymentName(name string) error {
+	if len(name) < azureNameMinLength || len(name) > azureNameMaxLength {
+		return fmt.Errorf("deployment name '%s' must be between %d and %d characters", name, azureNameMinLength, azureNameMaxLength)
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !isASCIIAlphanumeric(c) && c != '-' && c != '_' && c != '.' {
+			return fmt.Errorf("deployment name '%s' contains '%c'; only letters, digits, hyphens, underscores and periods are allowed", name, c)
+		}
+	}
+	return nil
+}
+
+// isValidEnvVarName reports whether name is a portable environment variable name:
+// letters, digits and underscores, not starting with a digit
+func isValidEnvVarName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && !isASCIIAlphanumeric(c) || (i == 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// isASCIIAlphanumeric reports whether c is an ASCII letter or digit
+func isASCIIAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
 // validateURL validates that a string is a valid URL
 func validateURL(urlString string) error {
 	if urlString == "" {
@@ -255,6 +655,11 @@ func validateModelName(provider, model string) error {
 		return nil
 	}
 
+	// Models reported by the provider's models endpoint are always accepted
+	if models := loadCachedModels(provider); contains(models, strings.TrimPrefix(model, "models/")) {
+		return nil
+	}
+
 	switch provider {
 	case "openai":
 		// OpenAI model validation
@@ -280,6 +685,11 @@ func validateModelName(provider, model string) error {
 		if !strings.HasPrefix(model, "deepseek-") {
 			return fmt.Errorf("model '%s' may not be valid for DeepSeek (expected format: deepseek-*)", model)
 		}
+	case "mistral":
+		// Mistral model validation
+		if !strings.HasPrefix(model, "mistral-") && !strings.HasPrefix(model, "open-mistral-") {
+			return fmt.Errorf("model '%s' may not be valid for Mistral (expected format: mistral-* or open-mistral-*)", model)
+		}
 	}
 
 	return nil
@@ -287,8 +697,8 @@ func validateModelName(provider, model string) error {
 
 // isValidProvider checks if the provider name is supported
 func isValidProvider(provider string) bool {
-	validProviders := []string{"openai", "openai_compatible", "azure_openai", "anthropic", "gemini", "deepseek"}
-	return contains(validProviders, provider)
+	_, err := ParseProvider(provider)
+	return err == nil
 }
 
 // isValidAzureAPIVersion validates Azure OpenAI API version format
@@ -356,4 +766,40 @@ func hasVersionPrefix(urlString string) bool {
 	}
 
 	return false
-}
\ No newline at end of file
+}
+
+// hasVersionSegment reports whether the URL path ends with a version segment such as
+// /v1, /v1beta or /v1alpha2
+func hasVersionSegment(urlString string) bool {
+	trimmedURL := strings.TrimSuffix(urlString, "/")
+	lastSegment := trimmedURL[strings.LastIndex(trimmedURL, "/")+1:]
+
+	if len(lastSegment) < 2 || lastSegment[0] != 'v' {
+		return false
+	}
+
+	rest := strings.TrimLeft(lastSegment[1:], "0123456789")
+	if len(rest) == len(lastSegment)-1 {
+		// No digits after the v
+		return false
+	}
+	for _, stage := range []string{"alpha", "beta"} {
+		if strings.HasPrefix(rest, stage) {
+			rest = strings.TrimLeft(rest[len(stage):], "0123456789")
+			break
+		}
+	}
+	return rest == ""
+}
+
+// providerPathSuffix describes the path the provider client appends to base_url
+func providerPathSuffix(provider string) string {
+	switch provider {
+	case "gemini":
+		return "'/v1beta/models/...'"
+	case "deepseek":
+		return "'/chat/completions'"
+	default:
+		return "its API path"
+	}
+}
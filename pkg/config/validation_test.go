@@ -1,6 +1,8 @@
 package config
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -133,3 +135,568 @@ func TestValidateProviderConfig_OpenAICompatible(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateModelName_Mistral(t *testing.T) {
+	tests := []struct {
+		model       string
+		expectError bool
+	}{
+		{"mistral-small-latest", false},
+		{"mistral-large-2411", false},
+		{"open-mistral-nemo", false},
+		{"gpt-4o", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			err := validateModelName("mistral", tt.model)
+			if tt.expectError && err == nil {
+				t.Errorf("expected validation error for model %q, got none", tt.model)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no validation error for model %q, got: %v", tt.model, err)
+			}
+		})
+	}
+}
+
+func TestValidateProviderAvailable_MistralAndOpenRouter(t *testing.T) {
+	for _, provider := range []string{"mistral", "openrouter"} {
+		t.Run(provider, func(t *testing.T) {
+			cfg := DefaultConfig()
+			if err := cfg.ValidateProviderAvailable(provider); err == nil {
+				t.Errorf("expected %s to be unavailable without an API key", provider)
+			}
+
+			pc, err := cfg.GetProviderConfig(provider)
+			if err != nil {
+				t.Fatalf("GetProviderConfig(%q) returned error: %v", provider, err)
+			}
+			pc.APIKey = "test-key"
+
+			if err := cfg.ValidateProviderAvailable(provider); err != nil {
+				t.Errorf("expected %s to be available, got: %v", provider, err)
+			}
+			if key, err := cfg.GetAPIKey(provider); err != nil || key != "test-key" {
+				t.Errorf("GetAPIKey(%q) = %q, %v", provider, key, err)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("expected default config to validate, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestRefreshModels(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("unexpected request path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": [{"id": "o3-mini"}, {"id": "gpt-4.1"}]}`))
+	}))
+	defer server.Close()
+
+	if err := validateModelName("openai", "o3-mini"); err == nil {
+		t.Fatal("expected o3-mini to fail heuristic validation before refresh")
+	}
+
+	cfg := DefaultConfig()
+	cfg.OpenAI.BaseURL = server.URL
+	cfg.OpenAI.APIKey = "test-key"
+
+	if err := cfg.RefreshModels("openai"); err != nil {
+		t.Fatalf("RefreshModels returned error: %v", err)
+	}
+
+	if err := validateModelName("openai", "o3-mini"); err != nil {
+		t.Errorf("expected o3-mini to pass validation after refresh, got: %v", err)
+	}
+}
+
+func TestRefreshModels_GeminiKeyInHeader(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "" {
+			t.Errorf("expected no query string, got %q", r.URL.RawQuery)
+		}
+		if r.Header.Get("x-goog-api-key") != "gemini-key" {
+			t.Errorf("unexpected x-goog-api-key header: %q", r.Header.Get("x-goog-api-key"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"models": [{"name": "models/gemini-2.5-pro"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.Gemini.BaseURL = server.URL
+	cfg.Gemini.APIKey = "gemini-key"
+
+	if err := cfg.RefreshModels("gemini"); err != nil {
+		t.Fatalf("RefreshModels returned error: %v", err)
+	}
+
+	endpoint, err := modelsEndpoint("gemini", cfg.Gemini)
+	if err != nil || strings.Contains(endpoint, "gemini-key") {
+		t.Errorf("expected the API key to stay out of the URL, got %q, %v", endpoint, err)
+	}
+}
+
+func TestRefreshModels_Failure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.OpenAI.BaseURL = server.URL
+
+	if err := cfg.RefreshModels("openai"); err == nil {
+		t.Error("expected error when the models endpoint fails")
+	}
+
+	// Validation falls back to the prefix heuristic
+	if err := validateModelName("openai", "gpt-4o"); err != nil {
+		t.Errorf("expected gpt-4o to pass heuristic validation, got: %v", err)
+	}
+	if err := validateModelName("openai", "o3-mini"); err == nil {
+		t.Error("expected o3-mini to fail heuristic validation without a cache")
+	}
+}
+
+func TestValidateProviderAvailable_Ollama(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := cfg.ValidateProviderAvailable("ollama"); err != nil {
+		t.Errorf("expected ollama to be available without an API key, got: %v", err)
+	}
+
+	for _, provider := range []string{"openai", "openai_compatible", "anthropic", "gemini", "deepseek"} {
+		if err := cfg.ValidateProviderAvailable(provider); err == nil {
+			t.Errorf("expected %s to require an API key", provider)
+		}
+	}
+
+	cfg.Ollama = nil
+	if err := cfg.ValidateProviderAvailable("ollama"); err == nil {
+		t.Error("expected error when ollama is not configured")
+	}
+}
+
+func TestValidateForCI(t *testing.T) {
+	cfg := &Config{
+		OpenAI: &ProviderConfig{BaseURL: "ftp://api.openai.com"},
+		AzureOpenAI: &AzureOpenAIConfig{
+			ProviderConfig: ProviderConfig{APIKey: "azure-key"},
+		},
+		Anthropic: &ProviderConfig{Model: "sonnet", APIVersion: "2023-06-01"},
+	}
+
+	errors, warnings := cfg.ValidateForCI()
+
+	if len(errors) != 2 {
+		t.Errorf("expected 2 errors, got %d: %v", len(errors), errors)
+	}
+	for _, field := range []string{"openai.base_url", "azure_openai.deployment_name"} {
+		found := false
+		for _, msg := range errors {
+			if strings.Contains(msg, field) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected an error for %s, got: %v", field, errors)
+		}
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "anthropic.model") {
+		t.Errorf("expected a single anthropic.model warning, got: %v", warnings)
+	}
+}
+
+func TestValidate_WarnOnly(t *testing.T) {
+	cfg := &Config{Anthropic: &ProviderConfig{Model: "sonnet"}}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected model heuristic to fail validation by default")
+	}
+
+	t.Setenv("SMART_SUGGESTION_VALIDATE_WARN_ONLY", "true")
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected advisory checks to be non-fatal, got: %v", err)
+	}
+
+	cfg.Anthropic.BaseURL = "not a url"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected hard errors to remain fatal in warn-only mode")
+	}
+}
+
+func TestValidateProviderConfig_AnthropicVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiVersion    string
+		expectError   bool
+		expectWarning bool
+	}{
+		{name: "valid version", apiVersion: "2023-06-01"},
+		{name: "invalid version", apiVersion: "2023-6-1", expectError: true},
+		{name: "non-date version", apiVersion: "latest", expectError: true},
+		{name: "empty version", apiVersion: "", expectWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateProviderConfig("anthropic", &ProviderConfig{APIVersion: tt.apiVersion})
+
+			var hardErrors, warnings int
+			for _, err := range errors {
+				if err.Field != "anthropic.api_version" {
+					continue
+				}
+				if err.Warning {
+					warnings++
+				} else {
+					hardErrors++
+				}
+			}
+
+			if tt.expectError != (hardErrors > 0) {
+				t.Errorf("expected error=%v for api_version=%q, got: %v", tt.expectError, tt.apiVersion, errors)
+			}
+			if tt.expectWarning != (warnings > 0) {
+				t.Errorf("expected warning=%v for api_version=%q, got: %v", tt.expectWarning, tt.apiVersion, errors)
+			}
+		})
+	}
+
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("expected default config to validate, got: %v", err)
+	}
+}
+
+func TestValidateAllAvailable(t *testing.T) {
+	cfg := &Config{
+		OpenAI:    &ProviderConfig{APIKey: "sk-test"},
+		Anthropic: &ProviderConfig{},
+	}
+
+	results := cfg.ValidateAllAvailable()
+
+	if len(results) != 2 {
+		t.Fatalf("expected results for 2 configured providers, got %d: %v", len(results), results)
+	}
+	if err, ok := results["openai"]; !ok || err != nil {
+		t.Errorf("expected openai to be available, got %v (present=%v)", err, ok)
+	}
+	if err := results["anthropic"]; err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("expected anthropic API key error, got %v", err)
+	}
+	if _, ok := results["gemini"]; ok {
+		t.Error("expected unconfigured providers to be omitted")
+	}
+}
+
+func TestValidateProviderConfig_VersionSegment(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      string
+		baseURL       string
+		expectWarning bool
+	}{
+		{name: "gemini host only", provider: "gemini", baseURL: "https://generativelanguage.googleapis.com"},
+		{name: "gemini with /v1beta", provider: "gemini", baseURL: "https://generativelanguage.googleapis.com/v1beta", expectWarning: true},
+		{name: "gemini with /v1beta/", provider: "gemini", baseURL: "https://generativelanguage.googleapis.com/v1beta/", expectWarning: true},
+		{name: "gemini with /v1", provider: "gemini", baseURL: "https://generativelanguage.googleapis.com/v1", expectWarning: true},
+		{name: "deepseek host only", provider: "deepseek", baseURL: "https://api.deepseek.com"},
+		{name: "deepseek with /v1", provider: "deepseek", baseURL: "https://api.deepseek.com/v1", expectWarning: true},
+		{name: "openai with /v1 is not checked here", provider: "openai", baseURL: "https://api.openai.com/v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateProviderConfig(tt.provider, &ProviderConfig{BaseURL: tt.baseURL})
+
+			var warning *ValidationError
+			for i := range errors {
+				if errors[i].Field == tt.provider+".base_url" {
+					warning = &errors[i]
+				}
+			}
+
+			if !tt.expectWarning {
+				if warning != nil {
+					t.Errorf("expected no base_url warning for %s, got: %s", tt.baseURL, warning.Message)
+				}
+				return
+			}
+			if warning == nil {
+				t.Fatalf("expected a base_url warning for %s, got: %v", tt.baseURL, errors)
+			}
+			if !warning.Warning {
+				t.Error("expected the version segment check to be a warning")
+			}
+			if !strings.Contains(warning.Message, "version segment") {
+				t.Errorf("warning should mention the version segment, got: %s", warning.Message)
+			}
+		})
+	}
+
+	for url, want := range map[string]bool{
+		"https://example.com/v1beta":   true,
+		"https://example.com/v1alpha2": true,
+		"https://example.com/v2":       true,
+		"https://example.com/vbeta":    false,
+		"https://example.com/v1gamma":  false,
+		"https://example.com/valid":    false,
+		"https://example.com":          false,
+	} {
+		if got := hasVersionSegment(url); got != want {
+			t.Errorf("hasVersionSegment(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestValidateProviderConfig_EmbeddingBaseURL(t *testing.T) {
+	tests := []struct {
+		name             string
+		baseURL          string
+		embeddingBaseURL string
+		expectFields     []string
+	}{
+		{name: "both valid", baseURL: "https://chat.example.com", embeddingBaseURL: "https://embed.example.com"},
+		{name: "embedding unset", baseURL: "https://chat.example.com"},
+		{name: "invalid embedding only", baseURL: "https://chat.example.com", embeddingBaseURL: "ftp://embed.example.com", expectFields: []string{"openai.embedding_base_url"}},
+		{name: "invalid base only", baseURL: "ftp://chat.example.com", embeddingBaseURL: "https://embed.example.com", expectFields: []string{"openai.base_url"}},
+		{name: "both invalid", baseURL: "ftp://chat.example.com", embeddingBaseURL: "not a url", expectFields: []string{"openai.base_url", "openai.embedding_base_url"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateProviderConfig("openai", &ProviderConfig{BaseURL: tt.baseURL, EmbeddingBaseURL: tt.embeddingBaseURL})
+
+			if len(errors) != len(tt.expectFields) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.expectFields), len(errors), errors)
+			}
+			for i, field := range tt.expectFields {
+				if errors[i].Field != field {
+					t.Errorf("expected error for %s, got %s", field, errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_Headers(t *testing.T) {
+	tests := []struct {
+		name          string
+		globalHeaders map[string]string
+		headers       map[string]string
+		expectFields  []string
+	}{
+		{name: "valid headers", globalHeaders: map[string]string{"Proxy-Authorization": "Basic abc", "X-Trace-Id": "123"}, headers: map[string]string{"X-Team": "infra\tops"}},
+		{name: "bad global name", globalHeaders: map[string]string{"X Trace": "123"}, expectFields: []string{"global_headers"}},
+		{name: "empty name", globalHeaders: map[string]string{"": "123"}, expectFields: []string{"global_headers"}},
+		{name: "bad provider name", headers: map[string]string{"X-Team:": "infra"}, expectFields: []string{"openai.headers"}},
+		{name: "value with newline", globalHeaders: map[string]string{"X-Trace-Id": "123\r\nX-Injected: yes"}, expectFields: []string{"global_headers.X-Trace-Id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				GlobalHeaders: tt.globalHeaders,
+				OpenAI:        &ProviderConfig{Headers: tt.headers},
+			}
+
+			errors := cfg.validationErrors()
+			if len(errors) != len(tt.expectFields) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.expectFields), len(errors), errors)
+			}
+			for i, field := range tt.expectFields {
+				if errors[i].Field != field {
+					t.Errorf("expected error for %s, got %s", field, errors[i].Field)
+				}
+				if errors[i].Warning {
+					t.Errorf("expected %s to be a hard error", field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAzureOpenAIConfig_Names(t *testing.T) {
+	tests := []struct {
+		name           string
+		resourceName   string
+		deploymentName string
+		expectFields   []string
+	}{
+		{name: "valid names", resourceName: "awesome-corp", deploymentName: "gpt-4o_prod.v2"},
+		{name: "resource with illegal characters", resourceName: "awesome_corp.eu", deploymentName: "gpt-4o", expectFields: []string{"azure_openai.resource_name"}},
+		{name: "resource with leading hyphen", resourceName: "-awesome", deploymentName: "gpt-4o", expectFields: []string{"azure_openai.resource_name"}},
+		{name: "resource too long", resourceName: strings.Repeat("a", 65), deploymentName: "gpt-4o", expectFields: []string{"azure_openai.resource_name"}},
+		{name: "resource too short", resourceName: "a", deploymentName: "gpt-4o", expectFields: []string{"azure_openai.resource_name"}},
+		{name: "deployment with spaces", resourceName: "awesome-corp", deploymentName: "gpt 4o", expectFields: []string{"azure_openai.deployment_name"}},
+		{name: "deployment too long", resourceName: "awesome-corp", deploymentName: strings.Repeat("d", 65), expectFields: []string{"azure_openai.deployment_name"}},
+		{name: "both invalid", resourceName: "awesome corp", deploymentName: "gpt/4o", expectFields: []string{"azure_openai.resource_name", "azure_openai.deployment_name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateAzureOpenAIConfig(&AzureOpenAIConfig{
+				ProviderConfig: ProviderConfig{APIKey: "azure-key", APIVersion: "2024-10-21"},
+				ResourceName:   tt.resourceName,
+				DeploymentName: tt.deploymentName,
+			})
+
+			if len(errors) != len(tt.expectFields) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.expectFields), len(errors), errors)
+			}
+			for i, field := range tt.expectFields {
+				if errors[i].Field != field {
+					t.Errorf("expected error for %s, got %s", field, errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateProviderConfig_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		retry        RetryConfig
+		expectFields []string
+	}{
+		{name: "valid", retry: RetryConfig{MaxAttempts: 3, InitialBackoffMs: 200, MaxBackoffMs: 5000, Multiplier: 2}},
+		{name: "single attempt without backoff", retry: RetryConfig{MaxAttempts: 1, Multiplier: 1}},
+		{name: "zero attempts", retry: RetryConfig{MaxAttempts: 0, Multiplier: 2}, expectFields: []string{"openai.retry.max_attempts"}},
+		{name: "negative initial backoff", retry: RetryConfig{MaxAttempts: 3, InitialBackoffMs: -1, Multiplier: 2}, expectFields: []string{"openai.retry.initial_backoff_ms"}},
+		{name: "negative max backoff", retry: RetryConfig{MaxAttempts: 3, MaxBackoffMs: -5, Multiplier: 2}, expectFields: []string{"openai.retry.max_backoff_ms"}},
+		{name: "max below initial", retry: RetryConfig{MaxAttempts: 3, InitialBackoffMs: 1000, MaxBackoffMs: 500, Multiplier: 2}, expectFields: []string{"openai.retry.max_backoff_ms"}},
+		{name: "multiplier below one", retry: RetryConfig{MaxAttempts: 3, Multiplier: 0.5}, expectFields: []string{"openai.retry.multiplier"}},
+		{name: "all invalid", retry: RetryConfig{MaxAttempts: -1, InitialBackoffMs: -1, MaxBackoffMs: -1}, expectFields: []string{
+			"openai.retry.max_attempts", "openai.retry.initial_backoff_ms", "openai.retry.max_backoff_ms", "openai.retry.multiplier",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry := tt.retry
+			errors := validateProviderConfig("openai", &ProviderConfig{Retry: &retry})
+			if len(errors) != len(tt.expectFields) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.expectFields), len(errors), errors)
+			}
+			for i, field := range tt.expectFields {
+				if errors[i].Field != field {
+					t.Errorf("expected error for %s, got %s", field, errors[i].Field)
+				}
+				if errors[i].Warning {
+					t.Errorf("expected %s to be a hard error", field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAzureOpenAIConfig_Model(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       string
+		wantWarning bool
+	}{
+		{name: "no model", model: ""},
+		{name: "plausible model", model: "gpt-4o"},
+		{name: "versioned model", model: "gpt-4o-2024-08-06"},
+		{name: "garbage model", model: "my-prod-deployment", wantWarning: true},
+		{name: "other provider's model", model: "claude-3-5-sonnet-20241022", wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateAzureOpenAIConfig(&AzureOpenAIConfig{
+				ProviderConfig: ProviderConfig{APIKey: "azure-key", APIVersion: "2024-10-21", Model: tt.model},
+				ResourceName:   "awesome-corp",
+				DeploymentName: "prod",
+			})
+
+			var warnings ValidationErrors
+			for _, err := range errors {
+				if !err.Warning {
+					t.Errorf("expected only warnings, got error: %v", err)
+				}
+				if err.Field == "azure_openai.model" {
+					warnings = append(warnings, err)
+				}
+			}
+			if got := len(warnings) == 1; got != tt.wantWarning {
+				t.Errorf("expected model warning=%v, got %v", tt.wantWarning, errors)
+			}
+		})
+	}
+}
+
+func TestValidateProviderConfig_APIKeyEnv(t *testing.T) {
+	for name, valid := range map[string]bool{"MY_OPENAI_KEY": true, "_KEY2": true, "2KEY": false, "MY-KEY": false, "MY KEY": false} {
+		errors := validateProviderConfig("openai", &ProviderConfig{APIKeyEnv: name})
+		if valid && len(errors) != 0 {
+			t.Errorf("expected %q to be valid, got %v", name, errors)
+		}
+		if !valid && (len(errors) != 1 || errors[0].Field != "openai.api_key_env") {
+			t.Errorf("expected %q to be rejected, got %v", name, errors)
+		}
+	}
+}
+
+func TestValidateRequireHTTPS(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		wantErr bool
+	}{
+		{"public http", "http://api.example.com", true},
+		{"localhost", "http://localhost:11434", false},
+		{"loopback ip", "http://127.0.0.1:8080", false},
+		{"ipv6 loopback", "http://[::1]:11434", false},
+		{"https", "https://api.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				RequireHTTPS:     true,
+				OpenAICompatible: &ProviderConfig{BaseURL: tt.baseURL, Model: "llama3"},
+			}
+			errors := cfg.validationErrors()
+			if got := errors.hasField("openai_compatible.base_url"); got != tt.wantErr {
+				t.Errorf("expected error %v for %s, got %v", tt.wantErr, tt.baseURL, errors)
+			}
+
+			cfg.RequireHTTPS = false
+			if errors := cfg.validationErrors(); errors.hasField("openai_compatible.base_url") {
+				t.Errorf("expected %s to be allowed without require_https, got %v", tt.baseURL, errors)
+			}
+		})
+	}
+
+	disabled := false
+	cfg := &Config{
+		RequireHTTPS: true,
+		OpenAI:       &ProviderConfig{EmbeddingBaseURL: "http://embeddings.example.com"},
+		Mistral:      &ProviderConfig{BaseURL: "http://api.example.com", Enabled: &disabled},
+	}
+	errors := cfg.validationErrors()
+	if !errors.hasField("openai.embedding_base_url") {
+		t.Errorf("expected plain http embedding_base_url to be rejected, got %v", errors)
+	}
+	if errors.hasField("mistral.base_url") {
+		t.Errorf("expected disabled providers to be skipped, got %v", errors)
+	}
+}
@@ -0,0 +1,78 @@
+package privacy
+
+// FilterResult bundles what Analyze finds in a text
+type FilterResult struct {
+	// Filtered is the text as FilterText returns it
+	Filtered string
+	// Matches are the matches DetectSensitiveMatches reports
+	Matches []SensitiveMatch
+	Stats   FilterStats
+}
+
+// FilterStats summarizes the redactions in a FilterResult
+type FilterStats struct {
+	// Patterns names the patterns that redacted something, as DetectSensitivePatterns does
+	Patterns []string
+	// Redactions is the number of regions replaced by the pattern pass, after
+	// overlapping matches are merged
+	Redactions int
+	// RedactionRatio is the fraction of the text matches cover, as RedactionRatio reports
+	RedactionRatio float64
+}
+
+// Analyze filters text and reports its matches and statistics at once, running every
+// pattern over the text a single time instead of once for each of FilterText,
+// DetectSensitiveMatches, DetectSensitivePatterns and RedactionRatio. OnRedact is
+// called as it is by FilterText.
+func (f *Filter) Analyze(text string) *FilterResult {
+	result := &FilterResult{
+		Filtered: text,
+		Matches:  []SensitiveMatch{},
+		Stats:    FilterStats{Patterns: []string{}},
+	}
+	if !f.isActive() {
+		return result
+	}
+
+	skip := f.skipFunc(text)
+	var spans []redactionSpan
+	for _, pattern := range f.patterns {
+		if !f.appliesAtLevel(pattern) || (skip != nil && skip(pattern)) {
+			continue
+		}
+
+		redacted := false
+		for _, loc := range pattern.Pattern.FindAllStringIndex(text, -1) {
+			for _, span := range pattern.redactedSpans(text[loc[0]:loc[1]]) {
+				redacted = true
+				start, end := loc[0]+span[0], loc[0]+span[1]
+				result.Matches = append(result.Matches, SensitiveMatch{
+					PatternName: pattern.Name,
+					Category:    pattern.Category,
+					Severity:    pattern.Severity,
+					Matched:     text[start:end],
+					Start:       start,
+					End:         end,
+				})
+				if start != end {
+					spans = append(spans, redactionSpan{start: start, end: end, replacement: pattern.Replacement, name: pattern.Name})
+				}
+			}
+		}
+		if redacted {
+			result.Stats.Patterns = append(result.Stats.Patterns, pattern.Name)
+		}
+	}
+	result.Stats.RedactionRatio = redactionRatio(text, result.Matches)
+
+	// The matches only describe the pattern pass over text as given. When base64
+	// decoding or DotenvMode changed it, the pattern pass runs on the result instead.
+	if prepared := f.preprocess(text); prepared != text {
+		result.Filtered, result.Stats.Redactions = f.redactPatterns(prepared)
+		return result
+	}
+	merged := mergeSpans(spans)
+	result.Filtered = f.applySpans(text, merged)
+	result.Stats.Redactions = len(merged)
+	return result
+}
@@ -0,0 +1,49 @@
+package privacy
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// FilterTexts filters each of texts with FilterText, spreading the work over a pool of
+// up to GOMAXPROCS goroutines, and returns the results in input order. A Filter is
+// read-only after construction, so the workers share it without locking.
+func (f *Filter) FilterTexts(texts []string) []string {
+	if !f.isActive() {
+		return texts
+	}
+
+	filtered := make([]string, len(texts))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(texts) {
+		workers = len(texts)
+	}
+	if workers <= 1 {
+		for i, text := range texts {
+			filtered[i] = f.FilterText(text)
+		}
+		return filtered
+	}
+
+	// Workers claim the next unfiltered index, so long inputs do not hold up the rest
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(texts) {
+					return
+				}
+				filtered[i] = f.FilterText(texts[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return filtered
+}
@@ -0,0 +1,69 @@
+package privacy
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// minBearerTokenLength is the shortest bearer token that is redacted
+	minBearerTokenLength = 8
+	// tokenMarks are characters which, unlike plain words, credentials usually contain
+	tokenMarks = "0123456789._-"
+)
+
+// looksLikeBearerToken reports whether token could be a real credential rather than a
+// word such as "token" or "yes" in documentation and prose. Tokens must be at least
+// minBearerTokenLength long and contain one of tokenMarks, which covers JWTs and
+// opaque tokens alike.
+func looksLikeBearerToken(token string) bool {
+	return len(token) >= minBearerTokenLength && strings.ContainsAny(token, tokenMarks)
+}
+
+// bearerTokenSpans returns the span hook for the Bearer Token pattern, which redacts
+// the whole match when the captured token looks like a credential
+func bearerTokenSpans(re *regexp.Regexp) func(match string) [][2]int {
+	return func(match string) [][2]int {
+		groups := re.FindStringSubmatch(match)
+		if groups == nil || !looksLikeBearerToken(groups[1]) {
+			return nil
+		}
+		return [][2]int{{0, len(match)}}
+	}
+}
+
+// authSchemes are the lowercased Authorization schemes other than Bearer whose
+// credential follows the scheme word, as in "Authorization: Basic dXNlcjpwYXNz"
+var authSchemes = []string{"basic", "token", "apikey", "api-key", "key", "negotiate", "ntlm", "sso-key", "dpop"}
+
+// authorizationHeaderSpans returns the span hook for the Authorization Header pattern,
+// which captures the first word after the header name and, optionally, the word after
+// it. When the first word is a scheme such as Basic or Token, the credential after it
+// is redacted along with it. A Bearer scheme is left to the Bearer Token pattern, so
+// that placeholders such as "Authorization: Bearer <token>" stay readable, and short
+// words as in "the Authorization header" are kept.
+func authorizationHeaderSpans(re *regexp.Regexp) func(match string) [][2]int {
+	return func(match string) [][2]int {
+		loc := re.FindStringSubmatchIndex(match)
+		if loc == nil {
+			return nil
+		}
+		first := match[loc[2]:loc[3]]
+		hasCredential := loc[4] >= 0
+		if hasCredential && slices.Contains(authSchemes, strings.ToLower(first)) {
+			return [][2]int{{0, loc[5]}}
+		}
+		if strings.EqualFold(first, "bearer") {
+			return nil
+		}
+		if len(first) < minBearerTokenLength && !strings.ContainsAny(first, tokenMarks) {
+			return nil
+		}
+		if hasCredential {
+			// The second word is not part of a credential without a scheme
+			return [][2]int{{0, loc[3]}}
+		}
+		return [][2]int{{0, len(match)}}
+	}
+}
@@ -0,0 +1,82 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+)
+
+// maxCachedPatternSets bounds the number of compiled pattern sets kept by NewFilter.
+// When the cache is full it is emptied before the next set is added, which keeps
+// memory bounded for callers that build many distinct configurations.
+const maxCachedPatternSets = 64
+
+// patternCacheKey identifies a compiled pattern set by a hash of the configuration
+// fields compilePatterns reads
+type patternCacheKey [sha256.Size]byte
+
+var (
+	patternCacheMu sync.RWMutex
+	patternCache   = map[patternCacheKey][]SensitivePattern{}
+)
+
+// patternCacheKeyFor returns the cache key for config. Fields that only affect how
+// matches are applied, such as PreserveLength or DotenvMode, are left out so that
+// configs differing only in those share compiled patterns.
+func patternCacheKeyFor(config *FilterConfig) (patternCacheKey, bool) {
+	data, err := json.Marshal(struct {
+		Level                     FilterLevel
+		ReplacementText           string
+		MinSecretLength           int
+		JWTMode                   JWTMode
+		StandaloneValueMinEntropy float64
+		CustomPatterns            []string
+		CustomPatternSpecs        []CustomPatternSpec
+		LiteralDenylist           []string
+		RedactAllEmails           bool
+		RedactPEMBlocks           bool
+		PEMIncludeTypes           []string
+		PEMExcludeTypes           []string
+		SensitiveHosts            []string
+		Categories                []string
+	}{
+		config.Level,
+		config.ReplacementText,
+		config.MinSecretLength,
+		config.JWTMode,
+		config.StandaloneValueMinEntropy,
+		config.CustomPatterns,
+		config.CustomPatternSpecs,
+		config.LiteralDenylist,
+		config.RedactAllEmails,
+		config.RedactPEMBlocks,
+		config.PEMIncludeTypes,
+		config.PEMExcludeTypes,
+		config.SensitiveHosts,
+		config.Categories,
+	})
+	if err != nil {
+		return patternCacheKey{}, false
+	}
+	return sha256.Sum256(data), true
+}
+
+// cachedPatterns returns the compiled pattern set stored under key, if any
+func cachedPatterns(key patternCacheKey) ([]SensitivePattern, bool) {
+	patternCacheMu.RLock()
+	defer patternCacheMu.RUnlock()
+	patterns, ok := patternCache[key]
+	return patterns, ok
+}
+
+// storePatterns caches a compiled pattern set. The set is shared read-only between
+// filters: the regexps are safe for concurrent use, and span hooks bound to the filter
+// that compiled the set only read fields that are part of the key.
+func storePatterns(key patternCacheKey, patterns []SensitivePattern) {
+	patternCacheMu.Lock()
+	defer patternCacheMu.Unlock()
+	if len(patternCache) >= maxCachedPatternSets {
+		patternCache = map[patternCacheKey][]SensitivePattern{}
+	}
+	patternCache[key] = patterns
+}
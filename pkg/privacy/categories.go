@@ -0,0 +1,42 @@
+package privacy
+
+import "slices"
+
+// builtinCategories are the categories of the built-in patterns, which Categories
+// selects from
+var builtinCategories = []string{CategoryCredential, CategoryPII, CategoryNetwork, CategoryFinancial}
+
+// PIIOnlyConfig returns a filter configuration that redacts personal data, such as
+// email addresses, SSNs and phone numbers, and leaves API keys and other credentials
+// in place. It filters at strict level, where the SSN and phone patterns apply, and
+// redacts every email address.
+func PIIOnlyConfig() *FilterConfig {
+	config := DefaultFilterConfig()
+	config.Level = FilterLevelStrict
+	config.Categories = []string{CategoryPII}
+	config.RedactAllEmails = true
+	return config
+}
+
+// CredentialsOnlyConfig returns a filter configuration that redacts API keys, tokens,
+// passwords and other credentials at moderate level and leaves personal data such as
+// email addresses in place
+func CredentialsOnlyConfig() *FilterConfig {
+	config := DefaultFilterConfig()
+	config.Level = FilterLevelModerate
+	config.Categories = []string{CategoryCredential}
+	return config
+}
+
+// includesCategory reports whether built-in patterns of category are compiled
+func (f *Filter) includesCategory(category string) bool {
+	return len(f.config.Categories) == 0 || slices.Contains(f.config.Categories, category)
+}
+
+// selectCategories drops the built-in patterns outside the configured Categories.
+// Custom and denylist patterns are kept, since they were asked for explicitly.
+func (f *Filter) selectCategories() {
+	f.patterns = slices.DeleteFunc(f.patterns, func(pattern SensitivePattern) bool {
+		return !pattern.custom && !f.includesCategory(pattern.Category)
+	})
+}
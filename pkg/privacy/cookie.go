@@ -0,0 +1,56 @@
+package privacy
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// sessionCookieParts are the words, lowercased, that mark a cookie name whose value the
+// Session Cookie pattern redacts when they appear as a whole part of the name, as in
+// connect.sid, auth_token or XSRF-TOKEN
+var sessionCookieParts = []string{"session", "sid", "csrf", "xsrf", "auth"}
+
+// sessionCookieCompounds are common session cookie names, lowercased, whose words are
+// run together
+var sessionCookieCompounds = []string{"sessionid", "phpsessid", "jsessionid", "csrftoken", "authtoken"}
+
+// cookiePairPattern matches a name=value pair in a Cookie or Set-Cookie header
+var cookiePairPattern = regexp.MustCompile(`([^=;\s'"]+)=([^;\s'"]*)`)
+
+// cookieSpans is the span hook for the Session Cookie pattern, which matches a whole
+// Cookie or Set-Cookie header. The values of session, sid, csrf and auth cookies are
+// redacted and their names are kept. In a Set-Cookie header only the first pair is a
+// cookie; the rest are attributes such as Path and Expires.
+func cookieSpans(match string) [][2]int {
+	colon := strings.IndexByte(match, ':')
+	pairs := cookiePairPattern.FindAllStringSubmatchIndex(match[colon+1:], -1)
+	if strings.HasPrefix(strings.ToLower(match), "set-") && len(pairs) > 1 {
+		pairs = pairs[:1]
+	}
+
+	var spans [][2]int
+	for _, loc := range pairs {
+		name := strings.ToLower(match[colon+1+loc[2] : colon+1+loc[3]])
+		if loc[5] == loc[4] || !isSessionCookie(name) {
+			continue
+		}
+		spans = append(spans, [2]int{colon + 1 + loc[4], colon + 1 + loc[5]})
+	}
+	return spans
+}
+
+// isSessionCookie reports whether a lowercased cookie name holds a session or
+// anti-forgery token. The name is split on '.', '_' and '-', so sidebar and author
+// are not mistaken for sid and auth.
+func isSessionCookie(name string) bool {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '.' || r == '_' || r == '-'
+	})
+	for _, part := range parts {
+		if slices.Contains(sessionCookieParts, part) || slices.Contains(sessionCookieCompounds, part) {
+			return true
+		}
+	}
+	return false
+}
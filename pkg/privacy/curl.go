@@ -0,0 +1,27 @@
+package privacy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// curlParameterPattern matches a name=value parameter in a curl or wget command: one
+// in a URL query string, after ? or &, or the first one of a -d, --data or
+// --post-data body. Later body parameters follow an & and are matched as well.
+var curlParameterPattern = regexp.MustCompile(`(?i)(?:[?&]|(?:^|\s)(?:-d|--data(?:-raw|-binary|-urlencode)?|--post-data)(?:=|\s+)['"]?)([a-z0-9_.\-]+)=([^&\s'"#]+)`)
+
+// curlParameterSpans is the span hook for the Curl Secret Parameter pattern, which
+// matches a whole curl or wget command. The values of parameters with a sensitive
+// name, such as token or client_secret, are redacted when they are at least
+// MinSecretLength long. Shell variable references like $TOKEN are kept.
+func (f *Filter) curlParameterSpans(match string) [][2]int {
+	var spans [][2]int
+	for _, loc := range curlParameterPattern.FindAllStringSubmatchIndex(match, -1) {
+		name, value := match[loc[2]:loc[3]], match[loc[4]:loc[5]]
+		if !isSensitiveKey(name) || len(value) < f.minSecretLength() || strings.HasPrefix(value, "$") {
+			continue
+		}
+		spans = append(spans, [2]int{loc[4], loc[5]})
+	}
+	return spans
+}
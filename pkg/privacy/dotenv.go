@@ -0,0 +1,85 @@
+package privacy
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dotenvLinePattern matches NAME=value assignment lines as found in .env files
+var dotenvLinePattern = regexp.MustCompile(`(?m)^([ \t]*(?:export[ \t]+)?[A-Za-z_][A-Za-z0-9_]*[ \t]*=[ \t]*)([^\r\n]*)`)
+
+const (
+	// dotenvMinLength is the value length from which a dotenv value is treated as a secret
+	dotenvMinLength = 16
+	// dotenvMinEntropyLength is the shortest value checked against dotenvMinEntropy
+	dotenvMinEntropyLength = 8
+	// dotenvMinEntropy is the Shannon entropy, in bits per character, above which a
+	// shorter value is treated as a secret
+	dotenvMinEntropy = 3.0
+)
+
+// filterDotenv redacts the values of NAME=value lines that look like secrets,
+// keeping the variable names so the output stays readable
+func (f *Filter) filterDotenv(text string) string {
+	replacement := f.replacementFor("Dotenv Value")
+	return dotenvLinePattern.ReplaceAllStringFunc(text, func(line string) string {
+		groups := dotenvLinePattern.FindStringSubmatch(line)
+		if !isDotenvSecret(groups[2]) {
+			return line
+		}
+		return groups[1] + f.placeholder(replacement, groups[2])
+	})
+}
+
+// filterDotenvBytes is the []byte counterpart of filterDotenv
+func (f *Filter) filterDotenvBytes(b []byte) []byte {
+	return dotenvLinePattern.ReplaceAllFunc(b, func(line []byte) []byte {
+		return []byte(f.filterDotenv(string(line)))
+	})
+}
+
+// hasDotenvSecret reports whether filterDotenv would redact any line in text
+func hasDotenvSecret(text string) bool {
+	for _, groups := range dotenvLinePattern.FindAllStringSubmatch(text, -1) {
+		if isDotenvSecret(groups[2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDotenvSecret reports whether a dotenv value is long or random enough to be a secret.
+// Numbers, booleans, plain words, URLs without credentials, paths and phrases pass.
+func isDotenvSecret(value string) bool {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	if value == "" || strings.ContainsAny(value, " \t") {
+		return false
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return false
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "null", "none":
+		return false
+	}
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "./") || strings.HasPrefix(value, "~/") {
+		return false
+	}
+	if strings.Contains(value, "://") && !strings.Contains(value, "@") {
+		return false
+	}
+
+	// Words, hostnames and identifiers use a single character class; secrets mix them
+	if characterClasses(value) < 2 {
+		return false
+	}
+	if len(value) >= dotenvMinLength {
+		return true
+	}
+	return len(value) >= dotenvMinEntropyLength && shannonEntropy(value) >= dotenvMinEntropy
+}
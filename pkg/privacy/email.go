@@ -0,0 +1,21 @@
+package privacy
+
+import "strings"
+
+// emailAddressPattern matches a bare email address. It also consumes a URL scheme in
+// front of the address and a ":path" after it, so that emailSpans can tell URL
+// userinfo and scp-style git remotes apart from addresses.
+const emailAddressPattern = `(?:[a-zA-Z][a-zA-Z0-9+.-]*://)?[a-zA-Z0-9._%+-]+@(?:[a-zA-Z0-9-]+\.)+[a-zA-Z]{2,}\b(?::[^\s'"]+)?`
+
+// emailSpans is the span hook for the Email Address pattern. Matches such as
+// git@github.com:user/repo.git and ssh://git@github.com are remotes rather than email
+// addresses and are left in place; only the address itself is redacted otherwise.
+func emailSpans(match string) [][2]int {
+	if strings.Contains(match, "://") {
+		return nil
+	}
+	if at := strings.IndexByte(match, '@'); strings.Contains(match[at:], ":") {
+		return nil
+	}
+	return [][2]int{{0, len(match)}}
+}
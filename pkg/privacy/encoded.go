@@ -0,0 +1,82 @@
+package privacy
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// base64TokenPattern matches standalone tokens that look like standard or URL-safe base64
+var base64TokenPattern = regexp.MustCompile(`[A-Za-z0-9+/_\-]{16,}={0,2}`)
+
+// filterBase64Secrets redacts base64 tokens whose decoded text contains a secret
+// matched by the credential patterns. Tokens that do not decode to valid UTF-8 are left alone.
+func (f *Filter) filterBase64Secrets(text string) string {
+	return base64TokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		decoded, ok := decodeBase64(token)
+		if !ok || !f.containsCredential(decoded) {
+			return token
+		}
+		return f.placeholder(f.replacementFor("Base64 Encoded Secret"), token)
+	})
+}
+
+// filterBase64SecretsBytes is the []byte counterpart of filterBase64Secrets
+func (f *Filter) filterBase64SecretsBytes(b []byte) []byte {
+	return base64TokenPattern.ReplaceAllFunc(b, func(token []byte) []byte {
+		decoded, ok := decodeBase64(string(token))
+		if !ok || !f.containsCredential(decoded) {
+			return token
+		}
+		return []byte(f.placeholder(f.replacementFor("Base64 Encoded Secret"), string(token)))
+	})
+}
+
+// hasBase64Secret reports whether filterBase64Secrets would redact any token in text
+func (f *Filter) hasBase64Secret(text string) bool {
+	for _, token := range base64TokenPattern.FindAllString(text, -1) {
+		if decoded, ok := decodeBase64(token); ok && f.containsCredential(decoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsCredential reports whether text contains a match that a credential or custom
+// pattern up to the moderate level would redact. The broad standalone and strict
+// patterns are skipped since almost any decoded blob would match them, and the span
+// hooks are consulted so their false positives are ignored here too.
+func (f *Filter) containsCredential(text string) bool {
+	for _, pattern := range f.patterns {
+		if pattern.Level > FilterLevelModerate || pattern.Name == "Standalone Secret Value" {
+			continue
+		}
+		if pattern.Category != CategoryCredential && !pattern.custom {
+			continue
+		}
+		for _, match := range pattern.Pattern.FindAllString(text, -1) {
+			if pattern.redacts(match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeBase64 decodes token as standard or URL-safe base64, with or without padding,
+// and reports whether the result is valid UTF-8 text
+func decodeBase64(token string) (string, bool) {
+	encodings := []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding}
+	if strings.ContainsAny(token, "-_") {
+		encodings = []*base64.Encoding{base64.URLEncoding, base64.RawURLEncoding}
+	}
+
+	for _, encoding := range encodings {
+		decoded, err := encoding.DecodeString(token)
+		if err == nil && utf8.Valid(decoded) {
+			return string(decoded), true
+		}
+	}
+	return "", false
+}
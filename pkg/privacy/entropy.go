@@ -0,0 +1,99 @@
+package privacy
+
+import (
+	"math"
+	"strings"
+)
+
+// standaloneMinRunLength is the shortest alphanumeric run considered when checking
+// whether a standalone value looks random
+const standaloneMinRunLength = 16
+
+// standaloneSpans is the span hook for the Standalone Secret Value pattern. A trailing
+// \r from CRLF text is left in place, and unless the entropy guard is turned off the
+// value is only redacted when it looks random.
+func (f *Filter) standaloneSpans(match string) [][2]int {
+	value := strings.TrimSuffix(match, "\r")
+	if minEntropy := f.standaloneValueMinEntropy(); minEntropy > 0 && !looksRandom(value, minEntropy) {
+		return nil
+	}
+	return [][2]int{{0, len(value)}}
+}
+
+// looksRandom reports whether the longest alphanumeric run in value is long enough and has
+// at least minEntropy bits of entropy per character. Paths and filenames split into short
+// word-like runs at their separators and are not considered random.
+func looksRandom(value string, minEntropy float64) bool {
+	run := longestAlphanumericRun(value)
+	return len(run) >= standaloneMinRunLength && shannonEntropy(run) >= minEntropy
+}
+
+// longestAlphanumericRun returns the longest substring of s made of ASCII letters and digits
+func longestAlphanumericRun(s string) string {
+	longest, start := "", -1
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && isAlphanumeric(s[i]) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 && i-start > len(longest) {
+			longest = s[start:i]
+		}
+		start = -1
+	}
+	return longest
+}
+
+func isAlphanumeric(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// characterClasses counts how many of lowercase, uppercase, digits and symbols appear
+// in s. The separators '-', '.' and '_' are not counted as symbols.
+func characterClasses(s string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r == '-' || r == '.' || r == '_':
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
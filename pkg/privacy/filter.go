@@ -1,7 +1,11 @@
 package privacy
 
 import (
+	"fmt"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -21,218 +25,626 @@ const (
 
 // FilterConfig represents the configuration for privacy filtering
 type FilterConfig struct {
-	Level           FilterLevel `json:"level"`
-	Enabled         bool        `json:"enabled"`
-	CustomPatterns  []string    `json:"custom_patterns,omitempty"`
-	ReplacementText string      `json:"replacement_text,omitempty"`
+	Level              FilterLevel         `json:"level"`
+	Enabled            bool                `json:"enabled"`
+	CustomPatterns     []string            `json:"custom_patterns,omitempty"`
+	CustomPatternSpecs []CustomPatternSpec `json:"custom_pattern_specs,omitempty"`
+	ReplacementText    string              `json:"replacement_text,omitempty"`
+	// AlwaysApplyCustom applies custom patterns even at FilterLevelNone
+	AlwaysApplyCustom bool `json:"always_apply_custom,omitempty"`
+	// DotenvMode redacts long or high-entropy values of any NAME=value line, as in .env dumps
+	DotenvMode bool `json:"dotenv_mode,omitempty"`
+	// MinSecretLength is the shortest value the generic KEY/TOKEN/SECRET/PASSWORD rules
+	// redact. Zero means DefaultMinSecretLength. Rules for specific key formats keep their own lengths.
+	MinSecretLength int `json:"min_secret_length,omitempty"`
+	// JWTMode selects which JWT segments are redacted. Empty means JWTModeFull.
+	JWTMode JWTMode `json:"jwt_mode,omitempty"`
+	// StandaloneValueMinEntropy only redacts a line that is a bare long token if the token
+	// also looks random. Zero means DefaultStandaloneValueMinEntropy; a negative value turns
+	// the check off and redacts every such line.
+	StandaloneValueMinEntropy float64 `json:"standalone_value_min_entropy,omitempty"`
+	// PreserveLength replaces each redacted span with the first character of ReplacementText,
+	// or * if that is empty or not ASCII, repeated to the span's length, so the output keeps
+	// the input's width. Spans are what a pattern redacts: the whole match for most patterns,
+	// including any key name around a captured value, and overlapping spans are masked as one.
+	PreserveLength bool `json:"preserve_length,omitempty"`
+	// LiteralDenylist lists exact secret values to redact wherever they appear, matched
+	// case-sensitively. Entries shorter than MinDenylistLiteralLength are ignored so that
+	// short values cannot mangle ordinary text.
+	LiteralDenylist []string `json:"literal_denylist,omitempty"`
+	// RedactAllEmails redacts every email address at moderate level and above, not only
+	// those in auth contexts. user@host:path git remotes and URL userinfo are kept.
+	RedactAllEmails bool `json:"redact_all_emails,omitempty"`
+	// RedactPEMBlocks redacts whole -----BEGIN X----- ... -----END X----- blocks, such as
+	// certificates, DH parameters and private keys, at moderate level and above
+	RedactPEMBlocks bool `json:"redact_pem_blocks,omitempty"`
+	// PEMIncludeTypes limits RedactPEMBlocks to these block types, such as CERTIFICATE.
+	// Empty means every type.
+	PEMIncludeTypes []string `json:"pem_include_types,omitempty"`
+	// PEMExcludeTypes lists block types RedactPEMBlocks leaves in place, even if included
+	PEMExcludeTypes []string `json:"pem_exclude_types,omitempty"`
+	// SensitiveHosts lists domain suffixes, such as corp.internal or svc.cluster.local,
+	// whose hostnames and URLs are redacted at strict level
+	SensitiveHosts []string `json:"sensitive_hosts,omitempty"`
+	// HashReplacement replaces each redacted secret with a token derived from a salted
+	// hash of it, such as [REDACTED:1a2b3c4d], instead of ReplacementText. The same
+	// secret always gets the same token, so occurrences can be correlated.
+	HashReplacement bool `json:"hash_replacement,omitempty"`
+	// HashSalt keys the HashReplacement hash. When empty a random salt is chosen once
+	// per process, so tokens only match within a run.
+	HashSalt string `json:"hash_salt,omitempty"`
+	// RevealHead and RevealTail keep this many characters at the start and end of each
+	// redacted span, as in sk-a[REDACTED]wxyz, so a key can be recognized. Nothing is
+	// revealed when fewer than MinRevealHiddenLength characters would stay hidden.
+	RevealHead int `json:"reveal_head,omitempty"`
+	RevealTail int `json:"reveal_tail,omitempty"`
+	// ShellAware makes FilterMultilineText treat its input as a shell script and leave
+	// # comments unredacted, to cut noise from documentation examples. Commands, quoted
+	// strings and heredoc bodies are filtered as usual.
+	ShellAware bool `json:"shell_aware,omitempty"`
+	// Categories limits the built-in patterns to these categories, such as CategoryPII
+	// or CategoryCredential. Empty means every category. Custom patterns and
+	// LiteralDenylist entries apply regardless.
+	Categories []string `json:"categories,omitempty"`
+	// MaxLineLength is the longest line FilterMultilineText filters as a whole. Longer
+	// lines, such as minified JSON or large base64 blobs, are handled according to
+	// LongLinePolicy. Zero means DefaultMaxLineLength.
+	MaxLineLength int `json:"max_line_length,omitempty"`
+	// LongLinePolicy selects how lines longer than MaxLineLength are handled. Empty
+	// means LongLinePolicyChunk.
+	LongLinePolicy LongLinePolicy `json:"long_line_policy,omitempty"`
+}
+
+// MinDenylistLiteralLength is the shortest LiteralDenylist entry that is redacted
+const MinDenylistLiteralLength = 4
+
+// MinRevealHiddenLength is the fewest characters of a secret RevealHead and RevealTail
+// leave hidden. Shorter secrets are redacted whole.
+const MinRevealHiddenLength = 8
+
+// JWTMode controls how much of a JWT is redacted
+type JWTMode string
+
+const (
+	// JWTModeFull redacts the entire token
+	JWTModeFull JWTMode = "full"
+	// JWTModeSignatureOnly keeps the header and payload and redacts the signature
+	JWTModeSignatureOnly JWTMode = "signature_only"
+	// JWTModePayloadAndSignature keeps the header and redacts the payload and signature
+	JWTModePayloadAndSignature JWTMode = "payload_and_signature"
+)
+
+// Clone returns a deep copy of the filter configuration
+func (c *FilterConfig) Clone() *FilterConfig {
+	if c == nil {
+		return nil
+	}
+
+	clone := *c
+	if c.CustomPatterns != nil {
+		clone.CustomPatterns = append([]string{}, c.CustomPatterns...)
+	}
+	if c.CustomPatternSpecs != nil {
+		clone.CustomPatternSpecs = append([]CustomPatternSpec{}, c.CustomPatternSpecs...)
+	}
+	if c.LiteralDenylist != nil {
+		clone.LiteralDenylist = append([]string{}, c.LiteralDenylist...)
+	}
+	if c.PEMIncludeTypes != nil {
+		clone.PEMIncludeTypes = append([]string{}, c.PEMIncludeTypes...)
+	}
+	if c.PEMExcludeTypes != nil {
+		clone.PEMExcludeTypes = append([]string{}, c.PEMExcludeTypes...)
+	}
+	if c.SensitiveHosts != nil {
+		clone.SensitiveHosts = append([]string{}, c.SensitiveHosts...)
+	}
+	if c.Categories != nil {
+		clone.Categories = append([]string{}, c.Categories...)
+	}
+	return &clone
+}
+
+// CustomPatternSpec describes a custom pattern with matching options
+type CustomPatternSpec struct {
+	Pattern         string `json:"pattern"`
+	Literal         bool   `json:"literal,omitempty"`
+	WordBoundary    bool   `json:"word_boundary,omitempty"`
+	CaseInsensitive bool   `json:"case_insensitive,omitempty"`
+}
+
+// expression returns the regular expression for the spec with its options applied
+func (s CustomPatternSpec) expression() string {
+	expr := s.Pattern
+	if s.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if s.WordBoundary {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	if s.CaseInsensitive {
+		expr = `(?i)` + expr
+	}
+	return expr
 }
 
 // DefaultFilterConfig returns a default privacy filter configuration
 func DefaultFilterConfig() *FilterConfig {
 	return &FilterConfig{
-		Level:           FilterLevelBasic,
-		Enabled:         true,
-		CustomPatterns:  []string{},
-		ReplacementText: "[REDACTED]",
+		Level:                     FilterLevelBasic,
+		Enabled:                   true,
+		CustomPatterns:            []string{},
+		ReplacementText:           "[REDACTED]",
+		StandaloneValueMinEntropy: DefaultStandaloneValueMinEntropy,
+		MinSecretLength:           DefaultMinSecretLength,
 	}
 }
 
+// DefaultMinSecretLength is the default minimum value length for the generic secret rules
+const DefaultMinSecretLength = 8
+
+// DefaultStandaloneValueMinEntropy is the default entropy, in bits per character, a bare
+// long token needs to be redacted
+const DefaultStandaloneValueMinEntropy = 3.0
+
+// Pattern categories used to classify redactions
+const (
+	CategoryCredential = "credential"
+	CategoryPII        = "pii"
+	CategoryNetwork    = "network"
+	CategoryFinancial  = "financial"
+	CategoryCustom     = "custom"
+)
+
+// Pattern severities, from least to most sensitive
+const (
+	SeverityLow = iota + 1
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
 // SensitivePattern represents a pattern to detect sensitive information
 type SensitivePattern struct {
 	Name        string
 	Pattern     *regexp.Regexp
 	Replacement string
 	Level       FilterLevel
+	Category    string
+	Severity    int
+
+	custom bool
+	// spans, when set, returns the byte ranges of match to redact, relative to the
+	// start of the match. Returning no ranges leaves the match unredacted.
+	spans func(match string) [][2]int
+	// literals are the pattern's required literals, see requiredLiterals
+	literals []string
+}
+
+// redactedSpans returns the byte ranges of match that the pattern redacts
+func (p SensitivePattern) redactedSpans(match string) [][2]int {
+	if p.spans == nil {
+		return [][2]int{{0, len(match)}}
+	}
+	return p.spans(match)
+}
+
+// redacts reports whether any part of match would be replaced
+func (p SensitivePattern) redacts(match string) bool {
+	return len(p.redactedSpans(match)) > 0
 }
 
 // Filter represents the privacy filter with compiled patterns
 type Filter struct {
 	config   *FilterConfig
 	patterns []SensitivePattern
+
+	// OnRedact, when set, is called once for every region FilterText or FilterBytes
+	// redacts, with the name of the pattern and the region's byte offsets in the text
+	// being filtered. It never receives the redacted value. Only the pattern pass is
+	// reported: regions replaced by DotenvMode, strict base64 decoding or, in
+	// FilterMultilineText, the kubeconfig, Docker config and PEM block passes are not,
+	// and with DotenvMode or strict base64 decoding the offsets refer to the text after
+	// those passes have run. FilterLines and FilterMultilineText filter line by line, so
+	// their offsets are relative to the line, or to the chunk of a line longer than
+	// MaxLineLength. Set it before the filter is shared: the callback may be invoked
+	// concurrently when the filter is used from several goroutines, for example by
+	// FilterTexts, so it must be safe for concurrent use.
+	OnRedact func(patternName string, start, end int)
 }
 
-// NewFilter creates a new privacy filter with the given configuration
+// NewFilter creates a new privacy filter with the given configuration. The filter keeps
+// its own copy of config, so changes made to config afterwards do not affect it.
 func NewFilter(config *FilterConfig) *Filter {
 	if config == nil {
 		config = DefaultFilterConfig()
 	}
 
 	filter := &Filter{
-		config:   config,
+		config:   config.Clone(),
 		patterns: []SensitivePattern{},
 	}
 
+	// Filters built from matching configs share one compiled pattern set
+	key, cacheable := patternCacheKeyFor(filter.config)
+	if cacheable {
+		if patterns, ok := cachedPatterns(key); ok {
+			filter.patterns = patterns
+			return filter
+		}
+	}
+
 	filter.compilePatterns()
+	if cacheable {
+		filter.patterns = filter.patterns[:len(filter.patterns):len(filter.patterns)]
+		storePatterns(key, filter.patterns)
+	}
 	return filter
 }
 
-// compilePatterns compiles all the sensitive patterns based on the filter level
-func (f *Filter) compilePatterns() {
+// minSecretLength returns the configured minimum length for generic secret values
+func (f *Filter) minSecretLength() int {
+	if f.config.MinSecretLength <= 0 {
+		return DefaultMinSecretLength
+	}
+	return f.config.MinSecretLength
+}
+
+// standaloneValueMinEntropy returns the configured entropy guard for standalone values,
+// or zero when the guard is turned off
+func (f *Filter) standaloneValueMinEntropy() float64 {
+	switch {
+	case f.config.StandaloneValueMinEntropy == 0:
+		return DefaultStandaloneValueMinEntropy
+	case f.config.StandaloneValueMinEntropy < 0:
+		return 0
+	}
+	return f.config.StandaloneValueMinEntropy
+}
+
+// replacementFor returns the replacement text for the named pattern. A {name} placeholder
+// in the configured replacement text expands to the pattern name, and {{name}} produces
+// a literal {name}.
+func (f *Filter) replacementFor(name string) string {
 	replacementText := f.config.ReplacementText
 	if replacementText == "" {
-		replacementText = "[REDACTED]"
+		return "[REDACTED]"
+	}
+	if !strings.Contains(replacementText, "{name}") {
+		return replacementText
 	}
 
+	parts := strings.Split(replacementText, "{{name}}")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(part, "{name}", name)
+	}
+	return strings.Join(parts, "{name}")
+}
+
+// compilePatterns compiles all the sensitive patterns based on the filter level
+func (f *Filter) compilePatterns() {
+	// Minimum value length for the generic KEY/TOKEN/SECRET/PASSWORD rules
+	minLen := strconv.Itoa(f.minSecretLength())
+	// envValue matches a quoted or unquoted assignment value of at least minLen characters
+	envValue := `(?:'([^']{` + minLen + `,})'|"([^"]{` + minLen + `,})"|([^'"\s;&]{` + minLen + `,}))`
+
 	// Basic level patterns - common API keys and tokens
 	basicPatterns := []struct {
-		name    string
-		pattern string
+		name     string
+		pattern  string
+		category string
+		severity int
 	}{
 		// OpenAI API keys
-		{"OpenAI API Key", `sk-[a-zA-Z0-9]{48,}`},
-		{"OpenAI Project Key", `pk-[a-zA-Z0-9]{48,}`},
+		{"OpenAI API Key", `sk-[a-zA-Z0-9]{48,}`, CategoryCredential, SeverityHigh},
+		{"OpenAI Project Key", `pk-[a-zA-Z0-9]{48,}`, CategoryCredential, SeverityHigh},
+		
+		// Anthropic and DeepSeek keys also start with sk-, but differ from OpenAI keys in
+		// their sk-ant- prefix and their fixed length of 32 lowercase hex digits
+		{"Anthropic API Key", `\bsk-ant-[A-Za-z0-9_\-]{32,}`, CategoryCredential, SeverityHigh},
+		{"DeepSeek API Key", `\bsk-[a-f0-9]{32}\b`, CategoryCredential, SeverityHigh},
+		
+		// xAI (Grok) and Perplexity API keys
+		{"xAI API Key", `xai-[a-zA-Z0-9]{32,}`, CategoryCredential, SeverityHigh},
+		{"Perplexity API Key", `pplx-[a-zA-Z0-9]{32,}`, CategoryCredential, SeverityHigh},
+		
+		// Hugging Face and Replicate tokens
+		{"Hugging Face Token", `hf_[A-Za-z0-9]{34,}`, CategoryCredential, SeverityHigh},
+		{"Replicate API Token", `r8_[A-Za-z0-9]{37,}`, CategoryCredential, SeverityHigh},
+		
+		// Package registry publish tokens, standalone and as found in .npmrc and credentials.toml
+		{"npm Token", `npm_[A-Za-z0-9]{36}`, CategoryCredential, SeverityHigh},
+		{"PyPI Token", `pypi-[A-Za-z0-9_\-]{50,}`, CategoryCredential, SeverityHigh},
+		{"Cargo Registry Token", `\bcio[A-Za-z0-9]{32}\b`, CategoryCredential, SeverityHigh},
+		{"npmrc Auth Token", `//[^\s:]+/:_authToken=([^\s'"]+)`, CategoryCredential, SeverityHigh},
+		{"Cargo Credentials Token", `(?m)^\s*token\s*=\s*"([^"\s]{8,})"`, CategoryCredential, SeverityHigh},
+		
+		// Email and messaging service keys. A bare 32-hex Twilio auth token looks like any
+		// MD5 digest, so it is only matched after an account SID or a twilio/auth_token key.
+		{"SendGrid API Key", `\bSG\.[A-Za-z0-9_\-]{22}\.[A-Za-z0-9_\-]{43}`, CategoryCredential, SeverityHigh},
+		{"Twilio Account SID", `\bAC[0-9a-f]{32}\b`, CategoryCredential, SeverityMedium},
+		{"Twilio API Key SID", `\bSK[0-9a-f]{32}\b`, CategoryCredential, SeverityMedium},
+		{"Twilio Auth Token", `(?i)(?:\bAC[0-9a-f]{32}:([0-9a-f]{32})\b|(?:twilio[a-z_\-]*|auth[_\-]?token)['"]?\s*[=:]\s*['"]?([0-9a-f]{32})\b)`, CategoryCredential, SeverityHigh},
+		{"Mailgun API Key", `\bkey-[0-9a-f]{32}\b|\b[0-9a-f]{32}-[0-9a-f]{8}-[0-9a-f]{8}\b`, CategoryCredential, SeverityHigh},
 		
 		// Common API key patterns
-		{"Generic API Key", `(?i)api[_-]?key['"=:\s]+['"]*([a-zA-Z0-9_\-]{8,})['"]*`},
-		{"Bearer Token", `(?i)bearer\s+([a-zA-Z0-9_\-\.]{2,})`},
-		{"Authorization Header", `(?i)authorization['"=:\s]+['"]*([a-zA-Z0-9_\-\.]{2,})['"]*`},
+		{"Generic API Key", `(?i)api[_-]?key['"=:\s]+['"]*([a-zA-Z0-9_\-]{` + minLen + `,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Bearer Token", `(?i)bearer\s+([a-zA-Z0-9_\-\.]{2,})`, CategoryCredential, SeverityHigh},
+		{"Authorization Header", `(?i)authorization['"=:\s]+['"]*([a-zA-Z0-9_\-\.]{2,})(?:[ \t]+([a-zA-Z0-9_\-\.+/=]{2,}))?['"]*`, CategoryCredential, SeverityHigh},
 		
 		// Environment variable exports containing secrets
-		{"Export API Key", `(?i)export\s+[A-Z_]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*=['"]*([^'"'\s]{8,})['"]*`},
-		{"Set Environment", `(?i)set\s+[A-Z_]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*=['"]*([^'"'\s]{8,})['"]*`},
+		{"Export API Key", `(?i)export\s+[A-Z_]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*=['"]*([^'"'\s]{` + minLen + `,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Set Environment", `(?i)set\s+[A-Z_]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*=['"]*([^'"'\s]{` + minLen + `,})['"]*`, CategoryCredential, SeverityHigh},
 		
-		// Environment variable names containing KEY (broader pattern)
-		{"Env Var with KEY", `(?i)(?:export\s+|set\s+)?[A-Z_]*KEY[A-Z_]*=['"]*([^'"'\s]{8,})['"]*`},
-		{"Env Var with TOKEN", `(?i)(?:export\s+|set\s+)?[A-Z_]*TOKEN[A-Z_]*=['"]*([^'"'\s]{8,})['"]*`},
-		{"Env Var with SECRET", `(?i)(?:export\s+|set\s+)?[A-Z_]*SECRET[A-Z_]*=['"]*([^'"'\s]{8,})['"]*`},
-		{"Env Var with PASSWORD", `(?i)(?:export\s+|set\s+)?[A-Z_]*PASSWORD[A-Z_]*=['"]*([^'"'\s]{8,})['"]*`},
+		// Environment variable names containing KEY (broader pattern). Only the value is
+		// redacted. An unquoted value ends at ; or & as it does in the shell, so the other
+		// fields of connection strings (...;AccountKey=...;EndpointSuffix=...) and of query
+		// strings and form bodies (...&refresh_token=...&client_id=...) are kept. Rules that
+		// overlap these, such as OAuth Parameter, rely on this to keep parameter names.
+		{"Env Var with KEY", `(?i)(?:export\s+|set\s+)?[A-Z_]*KEY[A-Z_]*=` + envValue, CategoryCredential, SeverityHigh},
+		{"Env Var with TOKEN", `(?i)(?:export\s+|set\s+)?[A-Z_]*TOKEN[A-Z_]*=` + envValue, CategoryCredential, SeverityHigh},
+		{"Env Var with SECRET", `(?i)(?:export\s+|set\s+)?[A-Z_]*SECRET[A-Z_]*=` + envValue, CategoryCredential, SeverityHigh},
+		{"Env Var with PASSWORD", `(?i)(?:export\s+|set\s+)?[A-Z_]*PASSWORD[A-Z_]*=` + envValue, CategoryCredential, SeverityHigh},
 		
 		// Echo command outputs that reveal secrets
-		{"Echo API Key", `(?i)echo\s+\$[A-Z_]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*`},
-		{"Echo Env Var", `(?i)echo\s+\$[A-Z_]*(?:KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*`},
+		{"Echo API Key", `(?i)echo\s+\$[A-Z_]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*`, CategoryCredential, SeverityMedium},
+		{"Echo Env Var", `(?i)echo\s+\$[A-Z_]*(?:KEY|TOKEN|SECRET|PASSWORD)[A-Z_]*`, CategoryCredential, SeverityMedium},
 		
 		// Command substitution outputs
-		{"Command Substitution Secret", `(?i)\$\([^)]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[^)]*\)`},
+		{"Command Substitution Secret", `(?i)\$\([^)]*(?:API|KEY|TOKEN|SECRET|PASSWORD)[^)]*\)`, CategoryCredential, SeverityMedium},
 		
 		// Standalone secret values that might be command outputs
-		{"Standalone Secret Value", `(?m)^[a-zA-Z0-9_\-\.+/=]{20,}$`},
+		// A trailing \r is allowed so CRLF text behaves as it does when split into lines
+		{"Standalone Secret Value", `(?m)^[a-zA-Z0-9_\-\.+/=]{20,}\r?$`, CategoryCredential, SeverityMedium},
 		
 		// Lines that look like they contain revealed secrets (common patterns)
-		{"Revealed Secret Line", `(?i)(?:^|\s)(?:sk-[a-zA-Z0-9]{48,}|pk-[a-zA-Z0-9]{48,}|ghp_[a-zA-Z0-9]{36}|ghs_[a-zA-Z0-9]{36}|AKIA[0-9A-Z]{16}|xox[baprs]-[0-9a-zA-Z\-]{10,72})(?:\s|$)`},
+		{"Revealed Secret Line", `(?i)(?:^|\s)(?:sk-[a-zA-Z0-9]{48,}|pk-[a-zA-Z0-9]{48,}|ghp_[a-zA-Z0-9]{36}|ghs_[a-zA-Z0-9]{36}|AKIA[0-9A-Z]{16}|xox[baprs]-[0-9a-zA-Z\-]{10,72})(?:\s|$)`, CategoryCredential, SeverityHigh},
 		
 		// Common API key environment variable patterns
-		{"OpenAI API Key Env", `(?i)(?:export\s+|set\s+)?OPENAI_API_KEY=['"]*([^'"'\s]{8,})['"]*`},
-		{"Anthropic API Key Env", `(?i)(?:export\s+|set\s+)?ANTHROPIC_API_KEY=['"]*([^'"'\s]{8,})['"]*`},
-		{"Google API Key Env", `(?i)(?:export\s+|set\s+)?(?:GOOGLE_API_KEY|GEMINI_API_KEY)=['"]*([^'"'\s]{8,})['"]*`},
-		{"AWS Keys Env", `(?i)(?:export\s+|set\s+)?(?:AWS_ACCESS_KEY_ID|AWS_SECRET_ACCESS_KEY)=['"]*([^'"'\s]{8,})['"]*`},
-		{"GitHub Token Env", `(?i)(?:export\s+|set\s+)?(?:GITHUB_TOKEN|GH_TOKEN)=['"]*([^'"'\s]{8,})['"]*`},
-		{"Azure Keys Env", `(?i)(?:export\s+|set\s+)?(?:AZURE_CLIENT_SECRET|AZURE_TENANT_ID)=['"]*([^'"'\s]{8,})['"]*`},
-		{"Slack Token Env", `(?i)(?:export\s+|set\s+)?(?:SLACK_TOKEN|SLACK_BOT_TOKEN)=['"]*([^'"'\s]{8,})['"]*`},
-		{"DeepSeek API Key Env", `(?i)(?:export\s+|set\s+)?DEEPSEEK_API_KEY=['"]*([^'"'\s]{8,})['"]*`},
-		{"Stripe Keys Env", `(?i)(?:export\s+|set\s+)?(?:STRIPE_SECRET_KEY|STRIPE_PUBLISHABLE_KEY)=['"]*([^'"'\s]{8,})['"]*`},
-		{"Twilio Keys Env", `(?i)(?:export\s+|set\s+)?(?:TWILIO_AUTH_TOKEN|TWILIO_ACCOUNT_SID)=['"]*([^'"'\s]{8,})['"]*`},
-		{"SendGrid API Key Env", `(?i)(?:export\s+|set\s+)?SENDGRID_API_KEY=['"]*([^'"'\s]{8,})['"]*`},
-		{"Mailgun API Key Env", `(?i)(?:export\s+|set\s+)?MAILGUN_API_KEY=['"]*([^'"'\s]{8,})['"]*`},
-		{"Redis URL Env", `(?i)(?:export\s+|set\s+)?REDIS_URL=['"]*([^'"'\s]{8,})['"]*`},
-		{"MongoDB URI Env", `(?i)(?:export\s+|set\s+)?(?:MONGODB_URI|MONGO_URL)=['"]*([^'"'\s]{8,})['"]*`},
-		{"Database URL Env", `(?i)(?:export\s+|set\s+)?(?:DATABASE_URL|DB_URL)=['"]*([^'"'\s]{8,})['"]*`},
-		{"JWT Secret Env", `(?i)(?:export\s+|set\s+)?(?:JWT_SECRET|JWT_KEY)=['"]*([^'"'\s]{8,})['"]*`},
-		{"Encryption Key Env", `(?i)(?:export\s+|set\s+)?(?:ENCRYPTION_KEY|SECRET_KEY|SESSION_SECRET)=['"]*([^'"'\s]{8,})['"]*`},
-		{"Docker Registry Env", `(?i)(?:export\s+|set\s+)?(?:DOCKER_PASSWORD|REGISTRY_TOKEN)=['"]*([^'"'\s]{8,})['"]*`},
-		{"CI/CD Token Env", `(?i)(?:export\s+|set\s+)?(?:CI_TOKEN|GITLAB_TOKEN|JENKINS_TOKEN)=['"]*([^'"'\s]{8,})['"]*`},
-		{"Cloud Provider Keys", `(?i)(?:export\s+|set\s+)?(?:DIGITALOCEAN_TOKEN|VULTR_API_KEY|LINODE_TOKEN)=['"]*([^'"'\s]{8,})['"]*`},
+		{"OpenAI API Key Env", `(?i)(?:export\s+|set\s+)?OPENAI_API_KEY=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Anthropic API Key Env", `(?i)(?:export\s+|set\s+)?ANTHROPIC_API_KEY=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Google API Key Env", `(?i)(?:export\s+|set\s+)?(?:GOOGLE_API_KEY|GEMINI_API_KEY)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"AWS Keys Env", `(?i)(?:export\s+|set\s+)?(?:AWS_ACCESS_KEY_ID|AWS_SECRET_ACCESS_KEY)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"GitHub Token Env", `(?i)(?:export\s+|set\s+)?(?:GITHUB_TOKEN|GH_TOKEN)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"GitLab Token Env", `(?i)(?:export\s+|set\s+)?(?:GITLAB_TOKEN|GITLAB_PRIVATE_TOKEN|GL_TOKEN)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Bitbucket Token Env", `(?i)(?:export\s+|set\s+)?(?:BITBUCKET_TOKEN|BITBUCKET_APP_PASSWORD)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Azure Keys Env", `(?i)(?:export\s+|set\s+)?(?:AZURE_CLIENT_SECRET|AZURE_TENANT_ID)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Slack Token Env", `(?i)(?:export\s+|set\s+)?(?:SLACK_TOKEN|SLACK_BOT_TOKEN)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"DeepSeek API Key Env", `(?i)(?:export\s+|set\s+)?DEEPSEEK_API_KEY=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"xAI API Key Env", `(?i)(?:export\s+|set\s+)?(?:XAI_API_KEY|GROK_API_KEY)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Perplexity API Key Env", `(?i)(?:export\s+|set\s+)?(?:PERPLEXITY_API_KEY|PPLX_API_KEY)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Hugging Face Token Env", `(?i)(?:export\s+|set\s+)?(?:HF_TOKEN|HUGGING_FACE_HUB_TOKEN|HUGGINGFACE_TOKEN)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Replicate API Token Env", `(?i)(?:export\s+|set\s+)?REPLICATE_API_TOKEN=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Stripe Keys Env", `(?i)(?:export\s+|set\s+)?(?:STRIPE_SECRET_KEY|STRIPE_PUBLISHABLE_KEY)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Twilio Keys Env", `(?i)(?:export\s+|set\s+)?(?:TWILIO_AUTH_TOKEN|TWILIO_ACCOUNT_SID)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"SendGrid API Key Env", `(?i)(?:export\s+|set\s+)?SENDGRID_API_KEY=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Mailgun API Key Env", `(?i)(?:export\s+|set\s+)?MAILGUN_API_KEY=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Redis URL Env", `(?i)(?:export\s+|set\s+)?REDIS_URL=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"MongoDB URI Env", `(?i)(?:export\s+|set\s+)?(?:MONGODB_URI|MONGO_URL)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Database URL Env", `(?i)(?:export\s+|set\s+)?(?:DATABASE_URL|DB_URL)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"JWT Secret Env", `(?i)(?:export\s+|set\s+)?(?:JWT_SECRET|JWT_KEY)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Encryption Key Env", `(?i)(?:export\s+|set\s+)?(?:ENCRYPTION_KEY|SECRET_KEY|SESSION_SECRET)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Docker Registry Env", `(?i)(?:export\s+|set\s+)?(?:DOCKER_PASSWORD|REGISTRY_TOKEN)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"CI/CD Token Env", `(?i)(?:export\s+|set\s+)?(?:CI_TOKEN|JENKINS_TOKEN)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Cloud Provider Keys", `(?i)(?:export\s+|set\s+)?(?:DIGITALOCEAN_TOKEN|VULTR_API_KEY|LINODE_TOKEN)=['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		
+		// Webhook URLs and bot tokens grant full send capability
+		{"Slack Webhook URL", `https://hooks\.slack\.com/(?:services|workflows|triggers)/[A-Za-z0-9_/\-]+`, CategoryCredential, SeverityHigh},
+		{"Discord Webhook URL", `https://(?:(?:canary|ptb)\.)?discord(?:app)?\.com/api/webhooks/\d+/[A-Za-z0-9_\-]+`, CategoryCredential, SeverityHigh},
+		{"Telegram Bot Token", `\d{6,}:[A-Za-z0-9_\-]{35}`, CategoryCredential, SeverityHigh},
 		
 		// JWT tokens
-		{"JWT Token", `eyJ[a-zA-Z0-9_\-]*\.eyJ[a-zA-Z0-9_\-]*\.[a-zA-Z0-9_\-]*`},
+		{"JWT Token", `eyJ[a-zA-Z0-9_\-]*\.eyJ[a-zA-Z0-9_\-]*\.[a-zA-Z0-9_\-]*`, CategoryCredential, SeverityHigh},
 		
 		// Common secret patterns in command line
-		{"Password Parameter", `(?i)--password[=\s]+['"]*([^'"'\s]{4,})['"]*`},
-		{"Token Parameter", `(?i)--token[=\s]+['"]*([^'"'\s]{8,})['"]*`},
-		{"Secret Parameter", `(?i)--secret[=\s]+['"]*([^'"'\s]{8,})['"]*`},
+		{"Password Parameter", `(?i)--password[=\s]+['"]*([^'"'\s]{4,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Token Parameter", `(?i)--token[=\s]+['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Secret Parameter", `(?i)--secret[=\s]+['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
 		
 		// Database connection strings
-		{"Database URL", `(?i)(mysql|postgresql|mongodb|redis)://[^@]+:[^@]+@[^\s]+`},
+		{"Database URL", `(?i)(mysql|postgresql|mongodb|redis)://[^@]+:[^@]+@[^\s]+`, CategoryCredential, SeverityCritical},
+		{"Connection URI", `(?i)\b(?:redis|rediss|amqp|amqps|mongodb\+srv)://[^\s'"]+`, CategoryCredential, SeverityMedium},
+		
+		// Secrets passed as URL query parameters. Only the value is redacted.
+		{"URL Secret Parameter", `(?i)[?&](?:password|passwd|pwd|token|access_token|apikey|api_key)=([^&\s'"#]+)`, CategoryCredential, SeverityHigh},
 		
 		// Generic secrets in curl/wget commands
-		{"Curl Header Secret", `(?i)curl[^|]*-H['"]*[^'"]*(?:authorization|api[_-]?key|token)['"]*[=:]['"]*([^'"'\s]{8,})['"]*`},
-		{"Wget Header Secret", `(?i)wget[^|]*--header[='"]*[^'"]*(?:authorization|api[_-]?key|token)['"]*[=:]['"]*([^'"'\s]{8,})['"]*`},
+		{"Curl Header Secret", `(?i)curl[^|]*-H['"]*[^'"]*(?:authorization|api[_-]?key|token)['"]*[=:]['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Wget Header Secret", `(?i)wget[^|]*--header[='"]*[^'"]*(?:authorization|api[_-]?key|token)['"]*[=:]['"]*([^'"'\s]{8,})['"]*`, CategoryCredential, SeverityHigh},
+		{"Curl Secret Parameter", `(?i)\b(?:curl|wget)\b[^|;\n]*`, CategoryCredential, SeverityHigh},
 	}
 
 	// Add basic patterns
 	for _, p := range basicPatterns {
 		if compiled, err := regexp.Compile(p.pattern); err == nil {
-			f.patterns = append(f.patterns, SensitivePattern{
+			pattern := SensitivePattern{
 				Name:        p.name,
 				Pattern:     compiled,
-				Replacement: replacementText,
+				Replacement: f.replacementFor(p.name),
 				Level:       FilterLevelBasic,
-			})
+				Category:    p.category,
+				Severity:    p.severity,
+			}
+			switch p.name {
+			case "Bearer Token":
+				pattern.spans = bearerTokenSpans(compiled)
+			case "Authorization Header":
+				pattern.spans = authorizationHeaderSpans(compiled)
+			case "Standalone Secret Value":
+				pattern.spans = f.standaloneSpans
+			case "JWT Token":
+				pattern.spans = f.jwtSpans()
+			case "Curl Secret Parameter":
+				pattern.spans = f.curlParameterSpans
+			case "Revealed Secret Line":
+				// The surrounding whitespace anchors the match but is not part of the secret
+				pattern.spans = trimSpaceSpans
+			case "Env Var with KEY", "Env Var with TOKEN", "Env Var with SECRET", "Env Var with PASSWORD":
+				pattern.spans = submatchSpans(compiled)
+			case "URL Secret Parameter", "npmrc Auth Token", "Cargo Credentials Token", "Twilio Auth Token":
+				pattern.spans = submatchSpans(compiled)
+			}
+			f.patterns = append(f.patterns, pattern)
 		}
 	}
 
 	// Moderate level patterns - emails, IPs, more aggressive patterns
 	if f.config.Level >= FilterLevelModerate {
 		moderatePatterns := []struct {
-			name    string
-			pattern string
+			name     string
+			pattern  string
+			category string
+			severity int
 		}{
 			// Email addresses in sensitive contexts
-			{"Email in Auth", `(?i)(?:user|username|email|login)['"=:\s]+['"]*([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,})['"]*`},
-			{"Email in curl -u", `(?i)curl\s+[^|]*-u\s+([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}):([^@\s]+)`},
+			{"Email in Auth", `(?i)(?:user|username|email|login)['"=:\s]+['"]*([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,})['"]*`, CategoryPII, SeverityMedium},
+			{"Email in curl -u", `(?i)curl\s+[^|]*-u\s+([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}):([^@\s]+)`, CategoryPII, SeverityHigh},
 			
 			// IP addresses in sensitive contexts
-			{"Private IP", `(?:192\.168\.|10\.|172\.(?:1[6-9]|2[0-9]|3[01])\.)\d{1,3}\.\d{1,3}(?::\d+)?`},
+			{"Private IP", `(?:192\.168\.|10\.|172\.(?:1[6-9]|2[0-9]|3[01])\.)\d{1,3}\.\d{1,3}(?::\d+)?`, CategoryNetwork, SeverityLow},
 			
 			// SSH private key patterns
-			{"SSH Private Key", `-----BEGIN (?:RSA |EC |OPENSSH )?PRIVATE KEY-----`},
+			{"SSH Private Key", `-----BEGIN (?:RSA |EC |OPENSSH )?PRIVATE KEY-----`, CategoryCredential, SeverityCritical},
 			
 			// AWS keys
-			{"AWS Access Key", `AKIA[0-9A-Z]{16}`},
-			{"AWS Secret Key", `(?i)aws[_-]?secret[_-]?access[_-]?key['"=:\s]+['"]*([a-zA-Z0-9/+]{40})['"]*`},
+			{"AWS Access Key", `AKIA[0-9A-Z]{16}`, CategoryCredential, SeverityHigh},
+			{"AWS Secret Key", `(?i)aws[_-]?secret[_-]?access[_-]?key['"=:\s]+['"]*([a-zA-Z0-9/+]{40})['"]*`, CategoryCredential, SeverityCritical},
 			
 			// GitHub tokens
-			{"GitHub Token", `ghp_[a-zA-Z0-9]{36}`},
-			{"GitHub App Token", `ghs_[a-zA-Z0-9]{36}`},
-			{"GitHub OAuth Token", `gho_[a-zA-Z0-9]{36}`},
+			{"GitHub Token", `ghp_[a-zA-Z0-9]{36}`, CategoryCredential, SeverityHigh},
+			{"GitHub App Token", `ghs_[a-zA-Z0-9]{36}`, CategoryCredential, SeverityHigh},
+			{"GitHub OAuth Token", `gho_[a-zA-Z0-9]{36}`, CategoryCredential, SeverityHigh},
+			
+			// GitLab and Bitbucket tokens
+			{"GitLab Token", `\bgl(?:pat|dt|rt|ptt)-[A-Za-z0-9_\-]{20,}`, CategoryCredential, SeverityHigh},
+			{"Bitbucket App Password", `\bATBB[A-Za-z0-9_=\-]{28,}`, CategoryCredential, SeverityHigh},
 			
 			// Slack tokens
-			{"Slack Token", `xox[baprs]-[0-9a-zA-Z-]{10,72}`},
+			{"Slack Token", `xox[baprs]-[0-9a-zA-Z-]{10,72}`, CategoryCredential, SeverityHigh},
 			
 			// More aggressive password detection
-			{"Password in URL", `(?i)://[^:@]+:([^@\s]{4,})@`},
+			{"Password in URL", `(?i)://[^:@]+:([^@\s]{4,})@`, CategoryCredential, SeverityCritical},
+			
+			// Azure Storage account keys in connection strings and SAS token signatures,
+			// as printed by az storage commands. Only the values are redacted.
+			{"Azure Storage Account Key", `(?i)AccountKey=([A-Za-z0-9+/]{20,}={0,2})`, CategoryCredential, SeverityCritical},
+			{"Azure SAS Signature", `(?i)[?&]sig=([^&\s'"#;]+)`, CategoryCredential, SeverityCritical},
+			
+			// OAuth2 tokens, client secrets and authorization codes in redirect URLs and
+			// form-encoded request bodies. Only the values are redacted.
+			{"OAuth Parameter", `(?i)(?:^|[?&#\s'"])(?:access_token|refresh_token|id_token|client_secret|code)=([^&\s'"#]{4,})`, CategoryCredential, SeverityHigh},
+			
+			// Google OAuth client secrets in client_secret.json and refresh tokens, which
+			// start with 1//. Only the client_secret value is redacted.
+			{"Google OAuth Client Secret", `"client_secret"\s*:\s*"([^"\s]+)"`, CategoryCredential, SeverityCritical},
+			{"Google OAuth Refresh Token", `\b1//[A-Za-z0-9_\-]{40,}`, CategoryCredential, SeverityHigh},
+			
+			// Session, sid, csrf and auth cookies in Cookie and Set-Cookie headers. Only
+			// the values are redacted.
+			{"Session Cookie", `(?i)\b(?:set-)?cookie:[^\r\n]*`, CategoryCredential, SeverityHigh},
 		}
 
 		for _, p := range moderatePatterns {
 			if compiled, err := regexp.Compile(p.pattern); err == nil {
-				f.patterns = append(f.patterns, SensitivePattern{
+				pattern := SensitivePattern{
 					Name:        p.name,
 					Pattern:     compiled,
-					Replacement: replacementText,
+					Replacement: f.replacementFor(p.name),
 					Level:       FilterLevelModerate,
-				})
+					Category:    p.category,
+					Severity:    p.severity,
+				}
+				switch p.name {
+				case "Azure Storage Account Key", "Azure SAS Signature", "OAuth Parameter", "Google OAuth Client Secret":
+					pattern.spans = submatchSpans(compiled)
+				case "Session Cookie":
+					pattern.spans = cookieSpans
+				}
+				f.patterns = append(f.patterns, pattern)
 			}
 		}
+
+		if f.config.RedactPEMBlocks {
+			f.patterns = append(f.patterns, SensitivePattern{
+				Name:        "PEM Block",
+				Pattern:     pemBlockPattern,
+				Replacement: f.replacementFor("PEM Block"),
+				Level:       FilterLevelModerate,
+				Category:    CategoryCredential,
+				Severity:    SeverityHigh,
+				spans:       f.pemBlockSpans,
+			})
+		}
+
+		if f.config.RedactAllEmails {
+			f.patterns = append(f.patterns, SensitivePattern{
+				Name:        "Email Address",
+				Pattern:     regexp.MustCompile(emailAddressPattern),
+				Replacement: f.replacementFor("Email Address"),
+				Level:       FilterLevelModerate,
+				Category:    CategoryPII,
+				Severity:    SeverityMedium,
+				spans:       emailSpans,
+			})
+		}
 	}
 
 	// Strict level patterns - very aggressive filtering
 	if f.config.Level >= FilterLevelStrict {
 		strictPatterns := []struct {
-			name    string
-			pattern string
+			name     string
+			pattern  string
+			category string
+			severity int
 		}{
 			// Any long alphanumeric strings that could be secrets
-			{"Potential Secret", `\b[a-zA-Z0-9]{32,}\b`},
+			{"Potential Secret", `\b[a-zA-Z0-9]{32,}\b`, CategoryCredential, SeverityLow},
 			
 			// Credit card numbers
-			{"Credit Card", `\b(?:4\d{3}|5[1-5]\d{2}|6011|65\d{2})\s*\d{4}\s*\d{4}\s*\d{4}\b`},
+			{"Credit Card", `\b(?:4\d{3}|5[1-5]\d{2}|6011|65\d{2})\s*\d{4}\s*\d{4}\s*\d{4}\b`, CategoryFinancial, SeverityCritical},
 			
 			// Social Security Numbers (US format)
-			{"SSN", `\b\d{3}-\d{2}-\d{4}\b`},
+			{"SSN", `\b\d{3}-\d{2}-\d{4}\b`, CategoryPII, SeverityCritical},
+			
+			// Bank account identifiers, redacted only when their checksum is valid
+			{"IBAN", `\b[A-Z]{2}\d{2}[A-Z0-9]{11,30}\b`, CategoryFinancial, SeverityCritical},
+			{"ABA Routing Number", `\b\d{9}\b`, CategoryFinancial, SeverityHigh},
 			
 			// Phone numbers in sensitive contexts
-			{"Phone Number", `(?i)(?:phone|tel|mobile)['"=:\s]+['"]*([+]?[\d\s\-\(\)]{10,})['"]*`},
+			{"Phone Number", `(?i)(?:phone|tel|mobile)['"=:\s]+['"]*([+]?[\d\s\-\(\)]{10,})['"]*`, CategoryPII, SeverityMedium},
 		}
 
 		for _, p := range strictPatterns {
 			if compiled, err := regexp.Compile(p.pattern); err == nil {
-				f.patterns = append(f.patterns, SensitivePattern{
+				pattern := SensitivePattern{
 					Name:        p.name,
 					Pattern:     compiled,
-					Replacement: replacementText,
+					Replacement: f.replacementFor(p.name),
 					Level:       FilterLevelStrict,
-				})
+					Category:    p.category,
+					Severity:    p.severity,
+				}
+				switch p.name {
+				case "IBAN":
+					pattern.spans = checksumSpans(validIBAN)
+				case "ABA Routing Number":
+					pattern.spans = checksumSpans(validABARouting)
+				}
+				f.patterns = append(f.patterns, pattern)
 			}
 		}
+
+		if suffixes := f.sensitiveHostSuffixes(); len(suffixes) > 0 {
+			f.patterns = append(f.patterns, SensitivePattern{
+				Name:        "Sensitive Host",
+				Pattern:     sensitiveHostPattern(suffixes),
+				Replacement: f.replacementFor("Sensitive Host"),
+				Level:       FilterLevelStrict,
+				Category:    CategoryNetwork,
+				Severity:    SeverityMedium,
+				spans:       f.sensitiveHostSpans,
+			})
+		}
 	}
 
 	// Add custom patterns
@@ -241,34 +653,174 @@ func (f *Filter) compilePatterns() {
 			f.patterns = append(f.patterns, SensitivePattern{
 				Name:        "Custom Pattern",
 				Pattern:     compiled,
-				Replacement: replacementText,
+				Replacement: f.replacementFor("Custom Pattern"),
+				Level:       FilterLevelBasic,
+				Category:    CategoryCustom,
+				Severity:    SeverityHigh,
+				custom:      true,
+			})
+		}
+	}
+
+	for _, spec := range f.config.CustomPatternSpecs {
+		if compiled, err := regexp.Compile(spec.expression()); err == nil {
+			f.patterns = append(f.patterns, SensitivePattern{
+				Name:        "Custom Pattern",
+				Pattern:     compiled,
+				Replacement: f.replacementFor("Custom Pattern"),
 				Level:       FilterLevelBasic,
+				Category:    CategoryCustom,
+				Severity:    SeverityHigh,
+				custom:      true,
 			})
 		}
 	}
+
+	for _, literal := range f.config.LiteralDenylist {
+		if len(literal) < MinDenylistLiteralLength {
+			continue
+		}
+		f.patterns = append(f.patterns, SensitivePattern{
+			Name:        "Denylisted Literal",
+			Pattern:     regexp.MustCompile(regexp.QuoteMeta(literal)),
+			Replacement: f.replacementFor("Denylisted Literal"),
+			Level:       FilterLevelBasic,
+			Category:    CategoryCustom,
+			Severity:    SeverityCritical,
+			custom:      true,
+		})
+	}
+
+	f.selectCategories()
+	for i := range f.patterns {
+		f.patterns[i].literals = requiredLiterals(f.patterns[i].Pattern)
+	}
+}
+
+// isActive reports whether any filtering applies with the current configuration
+func (f *Filter) isActive() bool {
+	if !f.config.Enabled {
+		return false
+	}
+	return f.config.Level != FilterLevelNone || f.config.AlwaysApplyCustom
+}
+
+// appliesAtLevel reports whether the pattern applies at the configured level.
+// At FilterLevelNone only custom patterns apply, and only with AlwaysApplyCustom.
+func (f *Filter) appliesAtLevel(pattern SensitivePattern) bool {
+	if f.config.Level == FilterLevelNone {
+		return f.config.AlwaysApplyCustom && pattern.custom
+	}
+	return pattern.Level <= f.config.Level
 }
 
 // FilterText filters sensitive information from the given text
 func (f *Filter) FilterText(text string) string {
-	if !f.config.Enabled || f.config.Level == FilterLevelNone {
+	if !f.isActive() {
 		return text
 	}
 
-	filtered := text
+	filtered, _ := f.redactPatterns(f.preprocess(text))
+	return filtered
+}
 
-	// Apply each pattern
-	for _, pattern := range f.patterns {
-		if pattern.Level <= f.config.Level {
-			filtered = pattern.Pattern.ReplaceAllString(filtered, pattern.Replacement)
-		}
+// preprocess runs the passes FilterText applies before the pattern pass: decoding
+// base64 blobs at strict level, before other patterns alter them, and DotenvMode
+func (f *Filter) preprocess(text string) string {
+	if f.config.Level >= FilterLevelStrict {
+		text = f.filterBase64Secrets(text)
 	}
+	if f.config.DotenvMode && f.config.Level >= FilterLevelBasic {
+		text = f.filterDotenv(text)
+	}
+	return text
+}
 
-	return filtered
+// redactPatterns redacts the matches of every pattern in text in a single pass and
+// returns the result with the number of regions replaced
+func (f *Filter) redactPatterns(text string) (string, int) {
+	spans := f.collectSpans(f.skipFunc(text), func(re *regexp.Regexp) [][]int {
+		return re.FindAllStringIndex(text, -1)
+	}, func(start, end int) string {
+		return text[start:end]
+	})
+	return f.applySpans(text, spans), len(spans)
+}
+
+// skipFunc returns a function reporting the patterns that cannot match text because
+// their required literals are missing, or nil if text cannot be checked that way.
+// Clean text usually skips every pattern.
+func (f *Filter) skipFunc(text string) func(SensitivePattern) bool {
+	lowered, ok := asciiLower(text)
+	if !ok {
+		return nil
+	}
+	return func(pattern SensitivePattern) bool {
+		return !pattern.mayMatch(lowered)
+	}
+}
+
+// applySpans replaces the merged spans of text with their placeholders
+func (f *Filter) applySpans(text string, spans []redactionSpan) string {
+	if len(spans) == 0 {
+		return text
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(text))
+	last := 0
+	for _, span := range spans {
+		f.notifyRedact(span)
+		builder.WriteString(text[last:span.start])
+		builder.WriteString(f.placeholder(span.replacement, text[span.start:span.end]))
+		last = span.end
+	}
+	builder.WriteString(text[last:])
+	return builder.String()
+}
+
+// FilterBytes filters sensitive information from b with the same semantics as
+// FilterText, without converting the whole buffer to a string
+func (f *Filter) FilterBytes(b []byte) []byte {
+	if !f.isActive() {
+		return b
+	}
+
+	filtered := b
+
+	// Decode base64 blobs before other patterns alter them
+	if f.config.Level >= FilterLevelStrict {
+		filtered = f.filterBase64SecretsBytes(filtered)
+	}
+
+	if f.config.DotenvMode && f.config.Level >= FilterLevelBasic {
+		filtered = f.filterDotenvBytes(filtered)
+	}
+
+	// Redact the matches of every pattern in a single pass
+	spans := f.collectSpans(nil, func(re *regexp.Regexp) [][]int {
+		return re.FindAllIndex(filtered, -1)
+	}, func(start, end int) string {
+		return string(filtered[start:end])
+	})
+	if len(spans) == 0 {
+		return filtered
+	}
+
+	result := make([]byte, 0, len(filtered))
+	last := 0
+	for _, span := range spans {
+		f.notifyRedact(span)
+		result = append(result, filtered[last:span.start]...)
+		result = append(result, f.placeholder(span.replacement, string(filtered[span.start:span.end]))...)
+		last = span.end
+	}
+	return append(result, filtered[last:]...)
 }
 
 // FilterLines filters sensitive information from multiple lines of text
 func (f *Filter) FilterLines(lines []string) []string {
-	if !f.config.Enabled || f.config.Level == FilterLevelNone {
+	if !f.isActive() {
 		return lines
 	}
 
@@ -280,30 +832,334 @@ func (f *Filter) FilterLines(lines []string) []string {
 	return filtered
 }
 
+// FilterLineRange filters only lines[start:end] and returns the full slice with those
+// lines replaced, for callers such as previews that only show part of a transcript.
+// start and end are clamped to the bounds of lines. The input slice is not modified:
+// a copy is made only when a line in the range changes, and lines outside the range
+// share their string data with the input.
+func (f *Filter) FilterLineRange(lines []string, start, end int) []string {
+	start = max(0, min(start, len(lines)))
+	end = max(start, min(end, len(lines)))
+	if !f.isActive() || start == end {
+		return lines
+	}
+
+	result := lines
+	copied := false
+	for i := start; i < end; i++ {
+		filtered := f.FilterText(lines[i])
+		if filtered == lines[i] {
+			continue
+		}
+		if !copied {
+			result = append([]string(nil), lines...)
+			copied = true
+		}
+		result[i] = filtered
+	}
+	return result
+}
+
 // FilterMultilineText filters sensitive information from multiline text
 func (f *Filter) FilterMultilineText(text string) string {
-	if !f.config.Enabled || f.config.Level == FilterLevelNone {
+	if !f.isActive() {
 		return text
 	}
 
-	lines := strings.Split(text, "\n")
-	filteredLines := f.FilterLines(lines)
-	return strings.Join(filteredLines, "\n")
+	// Structured credential files are redacted as a whole before line filtering
+	if f.config.Level >= FilterLevelBasic {
+		text = f.filterKubeconfig(text)
+		text = f.filterDockerConfig(text)
+	}
+	if f.config.RedactPEMBlocks && f.config.Level >= FilterLevelModerate {
+		text = f.filterPEMBlocks(text)
+	}
+
+	lines, endings := splitLines(text)
+
+	var filteredLines []string
+	if f.config.ShellAware {
+		filteredLines = f.filterShellLines(lines)
+	} else {
+		filteredLines = make([]string, len(lines))
+		for i, line := range lines {
+			filteredLines[i] = f.filterMultilineLine(line)
+		}
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(text))
+	for i, line := range filteredLines {
+		builder.WriteString(line)
+		builder.WriteString(endings[i])
+	}
+	return builder.String()
+}
+
+// splitLines splits text into lines and their original terminators ("\r\n", "\n",
+// or "" for a final line without a newline) so they can be rejoined byte-for-byte
+func splitLines(text string) ([]string, []string) {
+	var lines, endings []string
+
+	for len(text) > 0 {
+		idx := strings.IndexByte(text, '\n')
+		if idx == -1 {
+			lines = append(lines, text)
+			endings = append(endings, "")
+			break
+		}
+
+		line, ending := text[:idx], "\n"
+		if strings.HasSuffix(line, "\r") {
+			line, ending = line[:len(line)-1], "\r\n"
+		}
+		lines = append(lines, line)
+		endings = append(endings, ending)
+		text = text[idx+1:]
+	}
+
+	return lines, endings
 }
 
 // DetectSensitivePatterns returns information about detected sensitive patterns without filtering
 func (f *Filter) DetectSensitivePatterns(text string) []string {
-	if !f.config.Enabled || f.config.Level == FilterLevelNone {
+	if !f.isActive() {
 		return []string{}
 	}
 
 	var detected []string
 
 	for _, pattern := range f.patterns {
-		if pattern.Level <= f.config.Level && pattern.Pattern.MatchString(text) {
-			detected = append(detected, pattern.Name)
+		if !f.appliesAtLevel(pattern) {
+			continue
+		}
+		for _, match := range pattern.Pattern.FindAllString(text, -1) {
+			if pattern.redacts(match) {
+				detected = append(detected, pattern.Name)
+				break
+			}
 		}
 	}
 
 	return detected
-}
\ No newline at end of file
+}
+
+// WasFiltered reports whether FilterText would redact anything in text. It stops at
+// the first pattern that would redact a match and never builds the filtered string,
+// so it is cheaper than filtering and comparing, and it is still true when a
+// replacement happens to equal the text it replaced.
+func (f *Filter) WasFiltered(text string) bool {
+	if !f.isActive() {
+		return false
+	}
+
+	if f.config.Level >= FilterLevelStrict && f.hasBase64Secret(text) {
+		return true
+	}
+	if f.config.DotenvMode && f.config.Level >= FilterLevelBasic && hasDotenvSecret(text) {
+		return true
+	}
+
+	for _, pattern := range f.patterns {
+		if !f.appliesAtLevel(pattern) {
+			continue
+		}
+		if pattern.spans == nil {
+			if pattern.Pattern.MatchString(text) {
+				return true
+			}
+			continue
+		}
+		for _, match := range pattern.Pattern.FindAllString(text, -1) {
+			if pattern.redacts(match) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Explanation describes a single match that filtering would redact
+type Explanation struct {
+	PatternName string
+	Level       FilterLevel
+	Matched     string
+	LineNumber  int
+}
+
+// Explain reports every match of the active patterns in text, line by line, without filtering.
+// Matches are reported against the original text, so overlapping patterns each produce an entry.
+func (f *Filter) Explain(text string) []Explanation {
+	if !f.isActive() {
+		return []Explanation{}
+	}
+
+	explanations := []Explanation{}
+
+	for i, line := range strings.Split(text, "\n") {
+		for _, pattern := range f.patterns {
+			if !f.appliesAtLevel(pattern) {
+				continue
+			}
+			for _, match := range pattern.Pattern.FindAllString(line, -1) {
+				if !pattern.redacts(match) {
+					continue
+				}
+				explanations = append(explanations, Explanation{
+					PatternName: pattern.Name,
+					Level:       pattern.Level,
+					Matched:     match,
+					LineNumber:  i + 1,
+				})
+			}
+		}
+	}
+
+	return explanations
+}
+
+// TestPattern compiles pattern and reports whether it matches sample, along with the
+// matched substrings. It is intended for trying out custom patterns before adding them to config.
+func TestPattern(pattern, sample string) (bool, []string, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, nil, err
+	}
+
+	matches := compiled.FindAllString(sample, -1)
+	return len(matches) > 0, matches, nil
+}
+
+// ActivePatterns returns the patterns that apply at the configured level: built-in
+// patterns at or below the level, plus custom patterns, which at FilterLevelNone are
+// included only with AlwaysApplyCustom
+func (f *Filter) ActivePatterns() []SensitivePattern {
+	patterns := []SensitivePattern{}
+	if !f.isActive() {
+		return patterns
+	}
+
+	for _, pattern := range f.patterns {
+		if f.appliesAtLevel(pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// ListPatterns returns the patterns that apply at the configured level.
+//
+// Deprecated: use ActivePatterns, which returns the same patterns.
+func (f *Filter) ListPatterns() []SensitivePattern {
+	return f.ActivePatterns()
+}
+
+// SensitiveMatch describes a single match of a sensitive pattern in text
+type SensitiveMatch struct {
+	PatternName string
+	Category    string
+	Severity    int
+	Matched     string
+	Start       int
+	End         int
+}
+
+// DetectSensitiveMatches returns every match of the active patterns in text, with the
+// byte offsets of the part that would be redacted and the category and severity of its pattern
+func (f *Filter) DetectSensitiveMatches(text string) []SensitiveMatch {
+	matches := []SensitiveMatch{}
+
+	for _, pattern := range f.ActivePatterns() {
+		for _, loc := range pattern.Pattern.FindAllStringIndex(text, -1) {
+			for _, span := range pattern.redactedSpans(text[loc[0]:loc[1]]) {
+				start, end := loc[0]+span[0], loc[0]+span[1]
+				matches = append(matches, SensitiveMatch{
+					PatternName: pattern.Name,
+					Category:    pattern.Category,
+					Severity:    pattern.Severity,
+					Matched:     text[start:end],
+					Start:       start,
+					End:         end,
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// CollectMatches returns, for each pattern that matched, the distinct substrings it
+// would redact, in the order they first appear. It is meant for reviewing a corpus to
+// build allowlists, so unlike FilterText it returns the raw matched text; callers must
+// take care not to log or store the result carelessly.
+func (f *Filter) CollectMatches(text string) map[string][]string {
+	collected := map[string][]string{}
+	seen := map[string]map[string]bool{}
+
+	for _, match := range f.DetectSensitiveMatches(text) {
+		if match.Matched == "" {
+			continue
+		}
+		if seen[match.PatternName] == nil {
+			seen[match.PatternName] = map[string]bool{}
+		}
+		if seen[match.PatternName][match.Matched] {
+			continue
+		}
+		seen[match.PatternName][match.Matched] = true
+		collected[match.PatternName] = append(collected[match.PatternName], match.Matched)
+	}
+
+	return collected
+}
+
+// RedactionRatio estimates the fraction of bytes in text that would be replaced,
+// merging overlapping matches so each byte is only counted once
+func (f *Filter) RedactionRatio(text string) float64 {
+	return redactionRatio(text, f.DetectSensitiveMatches(text))
+}
+
+// redactionRatio returns the fraction of text covered by matches
+func redactionRatio(text string, matches []SensitiveMatch) float64 {
+	if len(text) == 0 || len(matches) == 0 {
+		return 0
+	}
+
+	matches = slices.Clone(matches)
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+
+	covered := 0
+	start, end := matches[0].Start, matches[0].End
+	for _, m := range matches[1:] {
+		if m.Start > end {
+			covered += end - start
+			start, end = m.Start, m.End
+			continue
+		}
+		if m.End > end {
+			end = m.End
+		}
+	}
+	covered += end - start
+
+	return float64(covered) / float64(len(text))
+}
+
+// DescribeLevel returns a human-readable summary of what the level redacts
+func DescribeLevel(level FilterLevel) string {
+	switch level {
+	case FilterLevelNone:
+		return "none: no filtering, except custom patterns when always_apply_custom is set"
+	case FilterLevelBasic:
+		return "basic: API keys, bearer tokens, JWTs, package registry tokens, SendGrid, Twilio and Mailgun keys, secrets in environment variables and in curl/wget headers, query strings and data bodies, webhook URLs and connection strings"
+	case FilterLevelModerate:
+		return "moderate: everything in basic, plus emails in credentials, private IP addresses, SSH private keys, AWS, GitHub, GitLab, Bitbucket and Slack tokens, Azure Storage account keys and SAS signatures, OAuth tokens and codes in URLs and form bodies, Google OAuth client secrets and refresh tokens, session and CSRF cookies, and passwords in URLs"
+	case FilterLevelStrict:
+		return "strict: everything in moderate, plus long random-looking strings, credit card numbers, SSNs, IBANs, bank routing numbers, phone numbers and base64 blobs that decode to secrets"
+	default:
+		return fmt.Sprintf("unknown level %d", level)
+	}
+}
@@ -0,0 +1,57 @@
+package privacy
+
+// checksumSpans wraps a checksum function as a span hook so that only matches with a
+// valid checksum are redacted. This keeps arbitrary codes and 9-digit numbers that
+// merely look like IBANs or routing numbers in the text.
+func checksumSpans(valid func(string) bool) func(match string) [][2]int {
+	return func(match string) [][2]int {
+		if !valid(match) {
+			return nil
+		}
+		return [][2]int{{0, len(match)}}
+	}
+}
+
+// validIBAN reports whether iban passes the ISO 13616 mod-97 check. The first four
+// characters are moved to the end, letters are expanded to 10-35, and the resulting
+// number must leave a remainder of 1 when divided by 97.
+func validIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	remainder := 0
+	rearranged := iban[4:] + iban[:4]
+	for i := 0; i < len(rearranged); i++ {
+		c := rearranged[i]
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// validABARouting reports whether number is a 9-digit ABA routing number with a valid
+// checksum: 3*(d1+d4+d7) + 7*(d2+d5+d8) + (d3+d6+d9) must be a multiple of 10. The
+// all-zero number passes the checksum but is never issued, so it is rejected.
+func validABARouting(number string) bool {
+	if len(number) != 9 || number == "000000000" {
+		return false
+	}
+
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < len(number); i++ {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += weights[i%3] * int(c-'0')
+	}
+	return sum%10 == 0
+}
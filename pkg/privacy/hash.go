@@ -0,0 +1,40 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+var (
+	runSaltOnce sync.Once
+	runSalt     []byte
+)
+
+// defaultHashSalt returns a random salt chosen once per process, used when HashSalt is
+// empty. Tokens are then stable within a run but cannot be compared across runs.
+func defaultHashSalt() []byte {
+	runSaltOnce.Do(func() {
+		runSalt = make([]byte, 32)
+		if _, err := rand.Read(runSalt); err != nil {
+			runSalt = nil
+		}
+	})
+	return runSalt
+}
+
+// hashReplacement returns the HashReplacement token for secret: the first 8 hex digits
+// of an HMAC-SHA256 of secret keyed by the salt, as in [REDACTED:1a2b3c4d]. Equal
+// secrets get equal tokens, so occurrences can be correlated without revealing them.
+func (f *Filter) hashReplacement(secret string) string {
+	salt := []byte(f.config.HashSalt)
+	if len(salt) == 0 {
+		salt = defaultHashSalt()
+	}
+
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(secret))
+	return "[REDACTED:" + hex.EncodeToString(mac.Sum(nil))[:8] + "]"
+}
@@ -0,0 +1,63 @@
+package privacy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// normalizeHostSuffix lowercases a SensitiveHosts entry and drops a leading "*." or
+// ".", so that "*.corp.internal", ".corp.internal" and "corp.internal" are the same
+func normalizeHostSuffix(suffix string) string {
+	suffix = strings.TrimPrefix(strings.TrimSpace(suffix), "*")
+	return strings.ToLower(strings.Trim(suffix, "."))
+}
+
+// sensitiveHostSuffixes returns the normalized, non-empty SensitiveHosts entries
+func (f *Filter) sensitiveHostSuffixes() []string {
+	var suffixes []string
+	for _, suffix := range f.config.SensitiveHosts {
+		if suffix = normalizeHostSuffix(suffix); suffix != "" {
+			suffixes = append(suffixes, suffix)
+		}
+	}
+	return suffixes
+}
+
+// sensitiveHostPattern compiles an alternation over the escaped suffixes that matches
+// a bare hostname or a URL whose host is a subdomain of one of them, together with any
+// scheme, userinfo, port and path. Labels after the suffix are consumed as well so
+// that sensitiveHostSpans can reject hosts like corp.internal.example.com.
+func sensitiveHostPattern(suffixes []string) *regexp.Regexp {
+	escaped := make([]string, len(suffixes))
+	for i, suffix := range suffixes {
+		escaped[i] = regexp.QuoteMeta(suffix)
+	}
+	return regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://)?(?:[^\s/@'"]+@)?(?:[a-z0-9-]+\.)*(?:` +
+		strings.Join(escaped, "|") + `)(?:\.[a-z0-9-]+)*\b(?::\d+)?(?:/[^\s'"]*)?`)
+}
+
+// sensitiveHostSpans is the span hook for the Sensitive Host pattern. The match is
+// redacted as a whole when its host is one of the SensitiveHosts suffixes or ends in one.
+func (f *Filter) sensitiveHostSpans(match string) [][2]int {
+	host := match
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndexByte(host, '@'); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.IndexByte(host, ':'); i >= 0 {
+		host = host[:i]
+	}
+	host = strings.ToLower(host)
+
+	for _, suffix := range f.sensitiveHostSuffixes() {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return [][2]int{{0, len(match)}}
+		}
+	}
+	return nil
+}
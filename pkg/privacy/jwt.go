@@ -0,0 +1,35 @@
+package privacy
+
+import "strings"
+
+// jwtSpans returns the span hook for the JWT Token pattern, or nil to redact whole
+// tokens. In the partial modes only the selected segments are redacted, keeping the
+// '.' separators so the structure stays visible.
+func (f *Filter) jwtSpans() func(string) [][2]int {
+	var keep int
+	switch f.config.JWTMode {
+	case JWTModeSignatureOnly:
+		keep = 2
+	case JWTModePayloadAndSignature:
+		keep = 1
+	default:
+		return nil
+	}
+
+	return func(match string) [][2]int {
+		segments := strings.Split(match, ".")
+		if len(segments) != 3 {
+			return [][2]int{{0, len(match)}}
+		}
+
+		var spans [][2]int
+		offset := 0
+		for i, segment := range segments {
+			if i >= keep {
+				spans = append(spans, [2]int{offset, offset + len(segment)})
+			}
+			offset += len(segment) + 1
+		}
+		return spans
+	}
+}
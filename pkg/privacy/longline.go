@@ -0,0 +1,73 @@
+package privacy
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxLineLength is the default MaxLineLength, 1 MiB
+const DefaultMaxLineLength = 1 << 20
+
+// LongLinePolicy controls how FilterMultilineText handles lines longer than MaxLineLength
+type LongLinePolicy string
+
+const (
+	// LongLinePolicyChunk filters a long line in pieces of at most MaxLineLength bytes,
+	// cut after whitespace or punctuation where possible. A secret straddling a cut
+	// that had to be made mid-token may be missed.
+	LongLinePolicyChunk LongLinePolicy = "chunk"
+	// LongLinePolicyRedact replaces a long line with a single placeholder
+	LongLinePolicyRedact LongLinePolicy = "redact"
+)
+
+// maxLineLength returns the configured maximum line length
+func (f *Filter) maxLineLength() int {
+	if f.config.MaxLineLength <= 0 {
+		return DefaultMaxLineLength
+	}
+	return f.config.MaxLineLength
+}
+
+// filterMultilineLine filters one line of FilterMultilineText input, handling a line
+// longer than MaxLineLength according to LongLinePolicy
+func (f *Filter) filterMultilineLine(line string) string {
+	if len(line) > f.maxLineLength() {
+		return f.filterLongLine(line)
+	}
+	return f.FilterText(line)
+}
+
+// filterLongLine filters a line longer than MaxLineLength according to LongLinePolicy
+func (f *Filter) filterLongLine(line string) string {
+	if f.config.LongLinePolicy == LongLinePolicyRedact {
+		return f.placeholder(f.replacementFor("Long Line"), line)
+	}
+
+	maxLen := f.maxLineLength()
+	var builder strings.Builder
+	builder.Grow(len(line))
+	for len(line) > 0 {
+		n := chunkLength(line, maxLen)
+		builder.WriteString(f.FilterText(line[:n]))
+		line = line[n:]
+	}
+	return builder.String()
+}
+
+// chunkLength returns the length of the next chunk of line, at most maxLen bytes. The
+// chunk ends after the last separator in its second half if there is one, so tokens
+// are kept whole, and otherwise on a UTF-8 character boundary.
+func chunkLength(line string, maxLen int) int {
+	if len(line) <= maxLen {
+		return len(line)
+	}
+	if i := strings.LastIndexAny(line[maxLen/2:maxLen], " \t,;&|"); i >= 0 {
+		return maxLen/2 + i + 1
+	}
+	for n := maxLen; n > 0; n-- {
+		if utf8.RuneStart(line[n]) {
+			return n
+		}
+	}
+	return maxLen
+}
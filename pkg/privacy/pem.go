@@ -0,0 +1,52 @@
+package privacy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pemBlockPattern matches a whole PEM block, from its BEGIN line to the first END
+// line. RE2 has no backreferences, so pemBlockSpans checks that the two labels agree.
+var pemBlockPattern = regexp.MustCompile(`(?s)-----BEGIN ([A-Z0-9][A-Z0-9 ]*)-----.*?-----END ([A-Z0-9][A-Z0-9 ]*)-----`)
+
+// pemBlockSpans is the span hook for the PEM Block pattern. A block is redacted as a
+// whole when its BEGIN and END labels match and its type is selected by the config.
+func (f *Filter) pemBlockSpans(match string) [][2]int {
+	groups := pemBlockPattern.FindStringSubmatch(match)
+	if groups == nil || groups[1] != groups[2] || !f.redactsPEMType(groups[1]) {
+		return nil
+	}
+	return [][2]int{{0, len(match)}}
+}
+
+// redactsPEMType reports whether PEM blocks of the given type, such as CERTIFICATE or
+// RSA PRIVATE KEY, are redacted. PEMExcludeTypes wins over PEMIncludeTypes, and an
+// empty PEMIncludeTypes selects every type.
+func (f *Filter) redactsPEMType(blockType string) bool {
+	for _, excluded := range f.config.PEMExcludeTypes {
+		if strings.EqualFold(excluded, blockType) {
+			return false
+		}
+	}
+	if len(f.config.PEMIncludeTypes) == 0 {
+		return true
+	}
+	for _, included := range f.config.PEMIncludeTypes {
+		if strings.EqualFold(included, blockType) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPEMBlocks redacts whole PEM blocks in multi-line text before it is split into
+// lines, since a block spans many lines and single lines cannot match it
+func (f *Filter) filterPEMBlocks(text string) string {
+	replacement := f.replacementFor("PEM Block")
+	return pemBlockPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if f.pemBlockSpans(match) == nil {
+			return match
+		}
+		return f.placeholder(replacement, match)
+	})
+}
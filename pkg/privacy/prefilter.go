@@ -0,0 +1,99 @@
+package privacy
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRequiredLiterals bounds the literals kept for one pattern. Alternations with more
+// branches than this are rarely worth checking one by one, so such patterns always run.
+const maxRequiredLiterals = 16
+
+// requiredLiterals returns lowercase strings at least one of which appears, ignoring
+// case, in every match of re. It returns nil when no such set is found, meaning re has
+// to be run on any text. This lets FilterText skip patterns that cannot match with a
+// few substring searches, which are much cheaper than running the regexps.
+func requiredLiterals(re *regexp.Regexp) []string {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	return literalsOf(parsed.Simplify())
+}
+
+// literalsOf returns the required literals of a parsed expression, see requiredLiterals
+func literalsOf(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{strings.ToLower(string(re.Rune))}
+	case syntax.OpCapture, syntax.OpPlus:
+		return literalsOf(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return literalsOf(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		// Every part of a concatenation is matched, so the most selective one will do
+		var best []string
+		for _, sub := range re.Sub {
+			if literals := literalsOf(sub); literals != nil && shortestLiteral(literals) > shortestLiteral(best) {
+				best = literals
+			}
+		}
+		return best
+	case syntax.OpAlternate:
+		// Any branch may match, so each needs literals of its own
+		var literals []string
+		for _, sub := range re.Sub {
+			branch := literalsOf(sub)
+			if branch == nil {
+				return nil
+			}
+			literals = append(literals, branch...)
+		}
+		if len(literals) > maxRequiredLiterals {
+			return nil
+		}
+		return literals
+	}
+	return nil
+}
+
+// shortestLiteral returns the length of the shortest of literals, or 0 if there are none
+func shortestLiteral(literals []string) int {
+	shortest := 0
+	for i, literal := range literals {
+		if i == 0 || len(literal) < shortest {
+			shortest = len(literal)
+		}
+	}
+	return shortest
+}
+
+// mayMatch reports whether the pattern can match text, given text lowercased. It
+// is always true for patterns without required literals.
+func (p SensitivePattern) mayMatch(lowered string) bool {
+	if p.literals == nil {
+		return true
+	}
+	for _, literal := range p.literals {
+		if strings.Contains(lowered, literal) {
+			return true
+		}
+	}
+	return false
+}
+
+// asciiLower returns text lowercased for mayMatch. It reports false for text with
+// non-ASCII characters, since (?i) also matches runes such as ſ for s that lowercasing
+// leaves alone, and every pattern must run on such text.
+func asciiLower(text string) (string, bool) {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return "", false
+		}
+	}
+	return strings.ToLower(text), true
+}
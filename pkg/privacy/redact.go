@@ -0,0 +1,137 @@
+package privacy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// redactionSpan is a byte range of text to replace and its replacement
+type redactionSpan struct {
+	start       int
+	end         int
+	replacement string
+	name        string
+}
+
+// collectSpans finds the redacted ranges of every applicable pattern and merges
+// overlapping or adjacent ranges, so each region is replaced exactly once. A merged
+// region uses the replacement and pattern name of the span that starts first, with ties going to the
+// pattern listed first. skip, when set, reports patterns known not to match the text,
+// find returns the match indexes of a pattern in the text and slice returns the text
+// between two offsets.
+func (f *Filter) collectSpans(skip func(SensitivePattern) bool, find func(*regexp.Regexp) [][]int, slice func(start, end int) string) []redactionSpan {
+	var spans []redactionSpan
+	for _, pattern := range f.patterns {
+		if !f.appliesAtLevel(pattern) || (skip != nil && skip(pattern)) {
+			continue
+		}
+		for _, loc := range find(pattern.Pattern) {
+			for _, span := range pattern.redactedSpans(slice(loc[0], loc[1])) {
+				if span[0] == span[1] {
+					continue
+				}
+				spans = append(spans, redactionSpan{
+					start:       loc[0] + span[0],
+					end:         loc[0] + span[1],
+					replacement: pattern.Replacement,
+					name:        pattern.Name,
+				})
+			}
+		}
+	}
+
+	return mergeSpans(spans)
+}
+
+// mergeSpans sorts spans by start offset, keeping the order of spans that start
+// together, and merges overlapping or adjacent ones as described for collectSpans
+func mergeSpans(spans []redactionSpan) []redactionSpan {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	merged := []redactionSpan{spans[0]}
+	for _, span := range spans[1:] {
+		current := &merged[len(merged)-1]
+		if span.start > current.end {
+			merged = append(merged, span)
+			continue
+		}
+		if span.end > current.end {
+			current.end = span.end
+		}
+	}
+	return merged
+}
+
+// notifyRedact reports a redacted span to the OnRedact callback, if one is set
+func (f *Filter) notifyRedact(span redactionSpan) {
+	if f.OnRedact != nil {
+		f.OnRedact(span.name, span.start, span.end)
+	}
+}
+
+// submatchSpans returns a span hook that redacts only the first capture group of re
+// that took part in the match, so the key in a key=value match stays readable
+func submatchSpans(re *regexp.Regexp) func(match string) [][2]int {
+	return func(match string) [][2]int {
+		loc := re.FindStringSubmatchIndex(match)
+		for i := 2; i+1 < len(loc); i += 2 {
+			if loc[i] >= 0 {
+				return [][2]int{{loc[i], loc[i+1]}}
+			}
+		}
+		return nil
+	}
+}
+
+// placeholder returns the text that replaces the redacted secret: replacement, a
+// salted hash of secret when HashReplacement is set, or a mask of the same length when
+// PreserveLength is set. The characters RevealHead and RevealTail keep are put around it.
+func (f *Filter) placeholder(replacement, secret string) string {
+	head, hidden, tail := f.revealedParts(secret)
+	if f.config.HashReplacement {
+		return head + f.hashReplacement(secret) + tail
+	}
+	if !f.config.PreserveLength {
+		return head + replacement + tail
+	}
+
+	mask := byte('*')
+	if text := f.config.ReplacementText; text != "" && text[0] < utf8.RuneSelf {
+		mask = text[0]
+	}
+	return head + strings.Repeat(string(mask), len(hidden)) + tail
+}
+
+// revealedParts splits secret into the first RevealHead and last RevealTail characters
+// and the hidden part between them. The whole secret is hidden when fewer than
+// MinRevealHiddenLength characters would remain.
+func (f *Filter) revealedParts(secret string) (head, hidden, tail string) {
+	headLength, tailLength := max(f.config.RevealHead, 0), max(f.config.RevealTail, 0)
+	if headLength == 0 && tailLength == 0 {
+		return "", secret, ""
+	}
+
+	runes := []rune(secret)
+	if len(runes)-headLength-tailLength < MinRevealHiddenLength {
+		return "", secret, ""
+	}
+	return string(runes[:headLength]), string(runes[headLength : len(runes)-tailLength]), string(runes[len(runes)-tailLength:])
+}
+
+// trimSpaceSpans is a span hook that redacts match without its leading and trailing whitespace
+func trimSpaceSpans(match string) [][2]int {
+	start := len(match) - len(strings.TrimLeft(match, " \t\r\n\f\v"))
+	end := len(strings.TrimRight(match, " \t\r\n\f\v"))
+	if start >= end {
+		return nil
+	}
+	return [][2]int{{start, end}}
+}
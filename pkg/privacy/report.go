@@ -0,0 +1,68 @@
+package privacy
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// DetectionReportEntry is one pattern's row in a DetectionReport. The JSON field names
+// are a stable contract for tooling.
+type DetectionReportEntry struct {
+	Name     string `json:"name"`
+	Level    string `json:"level"`
+	Category string `json:"category"`
+	Severity int    `json:"severity"`
+	Count    int    `json:"count"`
+}
+
+// DetectionReport returns a JSON array with one entry per pattern that would redact
+// something in text, in pattern order, with the number of matches it would redact.
+// Patterns sharing a name, such as custom patterns, are reported as one entry. Text
+// with no matches produces an empty array.
+func (f *Filter) DetectionReport(text string) ([]byte, error) {
+	entries := []DetectionReportEntry{}
+	index := map[string]int{}
+
+	for _, pattern := range f.ActivePatterns() {
+		count := 0
+		for _, match := range pattern.Pattern.FindAllString(text, -1) {
+			if pattern.redacts(match) {
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		if i, ok := index[pattern.Name]; ok {
+			entries[i].Count += count
+			continue
+		}
+		index[pattern.Name] = len(entries)
+		entries = append(entries, DetectionReportEntry{
+			Name:     pattern.Name,
+			Level:    levelName(pattern.Level),
+			Category: pattern.Category,
+			Severity: pattern.Severity,
+			Count:    count,
+		})
+	}
+
+	return json.Marshal(entries)
+}
+
+// levelName returns the configuration name of level, as accepted by SMART_SUGGESTION_PRIVACY_LEVEL
+func levelName(level FilterLevel) string {
+	switch level {
+	case FilterLevelNone:
+		return "none"
+	case FilterLevelBasic:
+		return "basic"
+	case FilterLevelModerate:
+		return "moderate"
+	case FilterLevelStrict:
+		return "strict"
+	default:
+		return strconv.Itoa(int(level))
+	}
+}
@@ -0,0 +1,37 @@
+package privacy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrSecretsFound is returned by ScanForSecrets when text contains sensitive matches
+var ErrSecretsFound = errors.New("secrets found")
+
+// ScanForSecrets reports every sensitive match in text using the filter's patterns and
+// level, and returns an error wrapping ErrSecretsFound when there is at least one. It is
+// meant for gating, such as pre-commit hooks or CI, where text should be rejected rather
+// than silently redacted.
+func (f *Filter) ScanForSecrets(text string) ([]SensitiveMatch, error) {
+	matches := f.DetectSensitiveMatches(text)
+	if len(matches) == 0 {
+		return matches, nil
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range matches {
+		if !seen[m.PatternName] {
+			seen[m.PatternName] = true
+			names = append(names, m.PatternName)
+		}
+	}
+
+	return matches, fmt.Errorf("%w: %d match(es) for %s", ErrSecretsFound, len(matches), strings.Join(names, ", "))
+}
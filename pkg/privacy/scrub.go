@@ -0,0 +1,36 @@
+package privacy
+
+import "sync"
+
+var (
+	// defaultFilters caches one filter per built-in level, built on first use.
+	// Filters are read-only after construction and safe for concurrent use.
+	defaultFilters     [FilterLevelStrict + 1]*Filter
+	defaultFiltersOnce [FilterLevelStrict + 1]sync.Once
+)
+
+// Scrub filters text with the default filter configuration
+func Scrub(text string) string {
+	return ScrubWithLevel(text, DefaultFilterConfig().Level)
+}
+
+// ScrubWithLevel filters text with the default filter configuration at the given level
+func ScrubWithLevel(text string, level FilterLevel) string {
+	return defaultFilterFor(level).FilterMultilineText(text)
+}
+
+// defaultFilterFor returns the cached default filter for level. Levels outside the
+// built-in range get a fresh filter on every call.
+func defaultFilterFor(level FilterLevel) *Filter {
+	config := DefaultFilterConfig()
+	config.Level = level
+
+	if level < FilterLevelNone || level > FilterLevelStrict {
+		return NewFilter(config)
+	}
+
+	defaultFiltersOnce[level].Do(func() {
+		defaultFilters[level] = NewFilter(config)
+	})
+	return defaultFilters[level]
+}
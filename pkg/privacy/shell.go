@@ -0,0 +1,136 @@
+package privacy
+
+import "strings"
+
+// heredoc is a pending here-document whose body starts on the next line
+type heredoc struct {
+	delimiter string
+	// stripTabs is set for <<- redirections, whose terminator may be indented with tabs
+	stripTabs bool
+}
+
+// shellScanner tracks the state a shell script carries from one line to the next:
+// an unterminated quote and the here-documents whose bodies are still to come
+type shellScanner struct {
+	quote    byte
+	heredocs []heredoc
+}
+
+// filterShellLines filters lines as a shell script for ShellAware mode. Comments are
+// left as they are, while commands, quoted strings spanning lines and heredoc bodies
+// are filtered. Heredoc bodies are filtered whole, so a # in them is not a comment.
+// Lines longer than MaxLineLength are scanned as well, so quote and heredoc state
+// carries across them, and only their filtering follows LongLinePolicy.
+func (f *Filter) filterShellLines(lines []string) []string {
+	var scanner shellScanner
+	filtered := make([]string, len(lines))
+
+	for i, line := range lines {
+		if len(scanner.heredocs) > 0 {
+			current := scanner.heredocs[0]
+			terminator := line
+			if current.stripTabs {
+				terminator = strings.TrimLeft(line, "\t")
+			}
+			if terminator == current.delimiter {
+				scanner.heredocs = scanner.heredocs[1:]
+				filtered[i] = line
+				continue
+			}
+			filtered[i] = f.filterMultilineLine(line)
+			continue
+		}
+
+		code, comment := scanner.splitComment(line)
+		filtered[i] = f.filterMultilineLine(code) + comment
+	}
+
+	return filtered
+}
+
+// splitComment splits line into its code and a trailing # comment, which starts at a
+// # beginning a word outside quotes. It records quotes left open at the end of the
+// line and heredocs started on it.
+func (s *shellScanner) splitComment(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch s.quote {
+		case '\'':
+			if c == '\'' {
+				s.quote = 0
+			}
+			continue
+		case '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				s.quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\\':
+			i++
+		case '\'', '"':
+			s.quote = c
+		case '#':
+			if i == 0 || isShellWordBreak(line[i-1]) {
+				return line[:i], line[i:]
+			}
+		case '<':
+			if strings.HasPrefix(line[i:], "<<<") {
+				i += 2
+			} else if strings.HasPrefix(line[i:], "<<") {
+				i = s.readHeredoc(line, i+2) - 1
+			}
+		}
+	}
+	return line, ""
+}
+
+// readHeredoc parses the delimiter of a << or <<- redirection starting at offset i of
+// line, just after the <<, records the heredoc and returns the offset after it
+func (s *shellScanner) readHeredoc(line string, i int) int {
+	doc := heredoc{}
+	if i < len(line) && line[i] == '-' {
+		doc.stripTabs = true
+		i++
+	}
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+
+	var delimiter strings.Builder
+	for i < len(line) && !isShellWordBreak(line[i]) && line[i] != '<' && line[i] != '>' {
+		if quote := line[i]; quote == '\'' || quote == '"' {
+			end := strings.IndexByte(line[i+1:], quote)
+			if end < 0 {
+				end = len(line) - i - 1
+			}
+			delimiter.WriteString(line[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		delimiter.WriteByte(line[i])
+		i++
+	}
+
+	if doc.delimiter = delimiter.String(); doc.delimiter != "" {
+		s.heredocs = append(s.heredocs, doc)
+	}
+	return min(i, len(line))
+}
+
+// isShellWordBreak reports whether c ends a shell word, so that a # after it starts a comment
+func isShellWordBreak(c byte) bool {
+	switch c {
+	case ' ', '\t', ';', '&', '|', '(', ')':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package privacy
+
+import (
+	"bytes"
+	"io"
+)
+
+// FilterWriter is an io.Writer that filters each complete line before writing it
+// to the underlying writer
+type FilterWriter struct {
+	filter *Filter
+	w      io.Writer
+	buf    []byte
+}
+
+// NewWriter returns a writer that filters sensitive information on the fly.
+// Partial writes are buffered until a newline, so a secret split across two Write
+// calls is still caught as long as it is within a single line. Call Flush or Close
+// to write out a final line that has no trailing newline.
+func (f *Filter) NewWriter(w io.Writer) *FilterWriter {
+	return &FilterWriter{
+		filter: f,
+		w:      w,
+	}
+}
+
+// Write buffers p and writes out every complete line after filtering it
+func (fw *FilterWriter) Write(p []byte) (int, error) {
+	fw.buf = append(fw.buf, p...)
+
+	for {
+		idx := bytes.IndexByte(fw.buf, '\n')
+		if idx == -1 {
+			break
+		}
+
+		if err := fw.writeLine(fw.buf[:idx], "\n"); err != nil {
+			return len(p), err
+		}
+		fw.buf = fw.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush filters and writes any buffered partial line
+func (fw *FilterWriter) Flush() error {
+	if len(fw.buf) == 0 {
+		return nil
+	}
+
+	err := fw.writeLine(fw.buf, "")
+	fw.buf = nil
+	return err
+}
+
+// Close flushes any buffered partial line. The underlying writer is not closed.
+func (fw *FilterWriter) Close() error {
+	return fw.Flush()
+}
+
+// writeLine filters a single line and writes it with its terminator
+func (fw *FilterWriter) writeLine(line []byte, ending string) error {
+	_, err := io.WriteString(fw.w, fw.filter.filterLine(line, ending))
+	return err
+}
+
+// FilterReader is an io.Reader that serves filtered lines read from an underlying reader
+type FilterReader struct {
+	filter  *Filter
+	r       io.Reader
+	pending []byte
+	out     []byte
+	err     error
+}
+
+// NewReader returns a reader whose data is filtered line by line as it is read.
+// Partial lines are buffered internally until their newline arrives, and a final
+// line without a newline is filtered and served when the underlying reader hits EOF.
+func (f *Filter) NewReader(r io.Reader) *FilterReader {
+	return &FilterReader{
+		filter: f,
+		r:      r,
+	}
+}
+
+// Read reads filtered data into p
+func (fr *FilterReader) Read(p []byte) (int, error) {
+	chunk := make([]byte, 4096)
+
+	for len(fr.out) == 0 && fr.err == nil {
+		n, err := fr.r.Read(chunk)
+		fr.pending = append(fr.pending, chunk[:n]...)
+
+		for {
+			idx := bytes.IndexByte(fr.pending, '\n')
+			if idx == -1 {
+				break
+			}
+			fr.out = append(fr.out, fr.filter.filterLine(fr.pending[:idx], "\n")...)
+			fr.pending = fr.pending[idx+1:]
+		}
+
+		if err != nil {
+			if len(fr.pending) > 0 {
+				fr.out = append(fr.out, fr.filter.filterLine(fr.pending, "")...)
+				fr.pending = nil
+			}
+			fr.err = err
+		}
+	}
+
+	if len(fr.out) == 0 {
+		return 0, fr.err
+	}
+
+	n := copy(p, fr.out)
+	fr.out = fr.out[n:]
+	return n, nil
+}
+
+// filterLine filters a single line and appends its terminator, keeping a trailing
+// carriage return out of the filtered text
+func (f *Filter) filterLine(line []byte, ending string) string {
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line, ending = line[:len(line)-1], "\r"+ending
+	}
+	return f.FilterText(string(line)) + ending
+}
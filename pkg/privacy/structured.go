@@ -0,0 +1,48 @@
+package privacy
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// kubeconfigDataPattern matches inline certificate and key data in kubeconfig users
+	kubeconfigDataPattern = regexp.MustCompile(`(?m)^(\s*(?:-\s+)?(?:client-certificate-data|client-key-data):\s*)(\S+)`)
+	// kubeconfigTokenPattern matches bearer tokens and passwords in kubeconfig users
+	kubeconfigTokenPattern = regexp.MustCompile(`(?m)^(\s*(?:-\s+)?(?:token|password):\s*)(\S+)`)
+	// kubeconfigMarkerPattern identifies kubeconfig-style content
+	kubeconfigMarkerPattern = regexp.MustCompile(`(?m)^\s*(?:kind:\s*Config|users:|current-context:)`)
+
+	// dockerAuthPattern matches credentials stored under auths in Docker config.json
+	dockerAuthPattern = regexp.MustCompile(`("(?:auth|identitytoken|registrytoken)"\s*:\s*")([^"]+)(")`)
+)
+
+// filterKubeconfig redacts credential values in kubeconfig content, such as the
+// output of kubectl config view, while leaving cluster and server names intact
+func (f *Filter) filterKubeconfig(text string) string {
+	text = f.redactSubmatch(kubeconfigDataPattern, text, "Kubeconfig Credential")
+
+	if kubeconfigMarkerPattern.MatchString(text) {
+		text = f.redactSubmatch(kubeconfigTokenPattern, text, "Kubeconfig Credential")
+	}
+
+	return text
+}
+
+// filterDockerConfig redacts the base64 auth values and tokens in Docker config.json content
+func (f *Filter) filterDockerConfig(text string) string {
+	if !strings.Contains(text, `"auths"`) {
+		return text
+	}
+	return f.redactSubmatch(dockerAuthPattern, text, "Docker Config Auth")
+}
+
+// redactSubmatch replaces the second capture group of every match with the replacement
+// for name, keeping the surrounding groups so keys and structure remain readable
+func (f *Filter) redactSubmatch(pattern *regexp.Regexp, text, name string) string {
+	replacement := f.replacementFor(name)
+	return pattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := pattern.FindStringSubmatch(match)
+		return groups[1] + f.placeholder(replacement, groups[2]) + strings.Join(groups[3:], "")
+	})
+}
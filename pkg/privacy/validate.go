@@ -0,0 +1,107 @@
+package privacy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate checks the configuration for values that would make the filter behave
+// unexpectedly: an unknown level, JWT mode, category or long line policy, custom
+// patterns that do not compile, negative lengths or thresholds, replacement text that
+// PreserveLength cannot use, and PreserveLength combined with HashReplacement.
+// All problems are reported together.
+func (c *FilterConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	var errs []error
+	if c.Level < FilterLevelNone || c.Level > FilterLevelStrict {
+		errs = append(errs, fmt.Errorf("level %d is out of range, must be between %d and %d", c.Level, FilterLevelNone, FilterLevelStrict))
+	}
+
+	for i, pattern := range c.CustomPatterns {
+		if err := validateCustomExpression(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("custom_patterns[%d]: %w", i, err))
+		}
+	}
+	for i, spec := range c.CustomPatternSpecs {
+		if spec.Pattern == "" {
+			errs = append(errs, fmt.Errorf("custom_pattern_specs[%d]: pattern is empty", i))
+			continue
+		}
+		if err := validateCustomExpression(spec.expression()); err != nil {
+			errs = append(errs, fmt.Errorf("custom_pattern_specs[%d]: %w", i, err))
+		}
+	}
+
+	if c.MinSecretLength < 0 {
+		errs = append(errs, fmt.Errorf("min_secret_length must not be negative, got %d", c.MinSecretLength))
+	}
+	if c.RevealHead < 0 {
+		errs = append(errs, fmt.Errorf("reveal_head must not be negative, got %d", c.RevealHead))
+	}
+	if c.RevealTail < 0 {
+		errs = append(errs, fmt.Errorf("reveal_tail must not be negative, got %d", c.RevealTail))
+	}
+
+	switch c.JWTMode {
+	case "", JWTModeFull, JWTModeSignatureOnly, JWTModePayloadAndSignature:
+	default:
+		errs = append(errs, fmt.Errorf("unknown jwt_mode %q, must be one of: %s, %s, %s", c.JWTMode, JWTModeFull, JWTModeSignatureOnly, JWTModePayloadAndSignature))
+	}
+
+	for i, category := range c.Categories {
+		if !slices.Contains(builtinCategories, category) {
+			errs = append(errs, fmt.Errorf("categories[%d]: unknown category %q, must be one of: %s", i, category, strings.Join(builtinCategories, ", ")))
+		}
+	}
+
+	if c.MaxLineLength < 0 {
+		errs = append(errs, fmt.Errorf("max_line_length must not be negative, got %d", c.MaxLineLength))
+	}
+	switch c.LongLinePolicy {
+	case "", LongLinePolicyChunk, LongLinePolicyRedact:
+	default:
+		errs = append(errs, fmt.Errorf("unknown long_line_policy %q, must be one of: %s, %s", c.LongLinePolicy, LongLinePolicyChunk, LongLinePolicyRedact))
+	}
+
+	if c.PreserveLength && c.ReplacementText != "" {
+		if first, _ := utf8.DecodeRuneInString(c.ReplacementText); first >= utf8.RuneSelf || first < ' ' {
+			errs = append(errs, fmt.Errorf("preserve_length needs replacement_text to start with a printable ASCII character, got %q", c.ReplacementText))
+		}
+	}
+
+	if c.PreserveLength && c.HashReplacement {
+		errs = append(errs, errors.New("preserve_length and hash_replacement cannot both be set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateCustomExpression reports whether expr compiles and cannot match the empty
+// string, which would insert a replacement between every character of the text
+func validateCustomExpression(expr string) error {
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	if compiled.MatchString("") {
+		return fmt.Errorf("pattern %q matches the empty string", expr)
+	}
+	return nil
+}
+
+// NewFilterValidated validates config and creates a filter from it. Unlike NewFilter,
+// which skips custom patterns that do not compile, it returns an error for any problem
+// Validate reports. A nil config uses DefaultFilterConfig.
+func NewFilterValidated(config *FilterConfig) (*Filter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid privacy filter config: %w", err)
+	}
+	return NewFilter(config), nil
+}
@@ -0,0 +1,96 @@
+package privacy
+
+import "strings"
+
+// sensitiveKeyParts are the key name fragments, lowercased and without separators,
+// that mark a map value as sensitive in FilterValue
+var sensitiveKeyParts = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"accesskey",
+	"privatekey",
+	"authorization",
+	"credential",
+	"cookie",
+}
+
+// isSensitiveKey reports whether a map key names a secret. Case, '-' and '_' are
+// ignored, so api_key, apiKey and API-KEY all match. A key of just "auth" matches, as
+// in Docker config files, but longer keys such as "author" do not.
+func isSensitiveKey(key string) bool {
+	normalized := strings.ToLower(key)
+	normalized = strings.NewReplacer("_", "", "-", "").Replace(normalized)
+	if normalized == "auth" {
+		return true
+	}
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(normalized, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterValue returns a filtered copy of v, a value such as one decoded from JSON.
+// Maps and slices are walked recursively and every string is passed through
+// FilterText. String values under a sensitive key, such as "password" or "api_key",
+// are replaced entirely, including strings inside a slice under such a key. Other
+// scalars, like numbers and booleans, pass through unchanged, so a count under a key
+// like "max_tokens" is kept. The input is not modified.
+func (f *Filter) FilterValue(v interface{}) interface{} {
+	if !f.isActive() {
+		return v
+	}
+	return f.filterValue(v, false)
+}
+
+// filterValue filters v, replacing strings outright when sensitive is set
+func (f *Filter) filterValue(v interface{}, sensitive bool) interface{} {
+	switch value := v.(type) {
+	case string:
+		return f.filterValueString(value, sensitive)
+	case map[string]interface{}:
+		filtered := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			filtered[key] = f.filterValue(item, f.redactsKey(key))
+		}
+		return filtered
+	case map[string]string:
+		filtered := make(map[string]string, len(value))
+		for key, item := range value {
+			filtered[key] = f.filterValueString(item, f.redactsKey(key))
+		}
+		return filtered
+	case []interface{}:
+		filtered := make([]interface{}, len(value))
+		for i, item := range value {
+			filtered[i] = f.filterValue(item, sensitive)
+		}
+		return filtered
+	case []string:
+		filtered := make([]string, len(value))
+		for i, item := range value {
+			filtered[i] = f.filterValueString(item, sensitive)
+		}
+		return filtered
+	default:
+		return v
+	}
+}
+
+// filterValueString filters a string found while walking a value
+func (f *Filter) filterValueString(value string, sensitive bool) string {
+	if sensitive && value != "" {
+		return f.placeholder(f.replacementFor("Sensitive Key"), value)
+	}
+	return f.FilterText(value)
+}
+
+// redactsKey reports whether values under key are redacted. Key names only count
+// once built-in filtering is on; at FilterLevelNone only custom patterns apply.
+func (f *Filter) redactsKey(key string) bool {
+	return f.config.Level != FilterLevelNone && isSensitiveKey(key)
+}